@@ -37,6 +37,7 @@ type Source struct {
 	CreatedAt             time.Time
 	Processed             bool
 	RelevantPerHumanCheck string
+	NumReportingSources   int
 }
 
 var RELEVANT_PER_HUMAN_CHECK_NO = "no"
@@ -298,7 +299,7 @@ func (a *App) loadSources() error {
 	}
 	defer conn.Close(ctx)
 
-	rows, err := conn.Query(ctx, "SELECT id, title, link, date, summary, importance_bool, importance_reasoning, created_at, processed FROM sources WHERE processed = false ORDER BY date ASC, id ASC") // AND DATE_PART('doy', date) < 34
+	rows, err := conn.Query(ctx, "SELECT id, title, link, date, summary, importance_bool, importance_reasoning, created_at, processed, COALESCE(num_reporting_sources, 0) FROM sources WHERE processed = false ORDER BY date ASC, id ASC") // AND DATE_PART('doy', date) < 34
 	// date '+%j'
 	if err != nil {
 		return fmt.Errorf("failed to query sources: %v", err)
@@ -308,7 +309,7 @@ func (a *App) loadSources() error {
 	var sources []Source
 	for rows.Next() {
 		var s Source
-		err := rows.Scan(&s.ID, &s.Title, &s.Link, &s.Date, &s.Summary, &s.ImportanceBool, &s.ImportanceReasoning, &s.CreatedAt, &s.Processed)
+		err := rows.Scan(&s.ID, &s.Title, &s.Link, &s.Date, &s.Summary, &s.ImportanceBool, &s.ImportanceReasoning, &s.CreatedAt, &s.Processed, &s.NumReportingSources)
 		if err != nil {
 			return fmt.Errorf("failed to scan row: %v", err)
 		}
@@ -397,6 +398,9 @@ func (a *App) draw() {
 
 		// title := fmt.Sprintf("[%s] %s | %s | %s", processedMark, padStringWithWhitespace(source.Title, 85), padStringWithWhitespace(host, 30), source.Date.Format("2006-01-02")) // why isn't the padding here working???
 		title := fmt.Sprintf("[%s] %s | %s | %s", processedMark, source.Title, host, source.Date.Format("2006-01-02")) // why isn't the padding here working???
+		if source.NumReportingSources > 0 {
+			title += fmt.Sprintf(" | %d sources", source.NumReportingSources)
+		}
 		// title := "[" + processedMark + "] " + padStringWithWhitespace(source.Title, 85) + " | " + padStringWithWhitespace(host, 30) + " | " + source.Date.Format("2006-01-02")
 		lineIdx = drawText(a.screen, 0, lineIdx, width, currentStyle, title)
 
@@ -87,7 +87,8 @@ func (a *App) loadSources() error {
 	}
 	defer conn.Close(ctx)
 
-	rows, err := conn.Query(ctx, "SELECT tweetid, text, author, url, created_at, processed FROM dwarkesh_tweets WHERE processed = false ORDER BY created_at ASC, tweetid ASC")
+	// importance_bool is NULL when the server's importance check is off
+	rows, err := conn.Query(ctx, "SELECT tweetid, text, author, url, created_at, processed FROM tweets WHERE processed = false AND importance_bool IS NOT FALSE ORDER BY created_at ASC, tweetid ASC")
 	if err != nil {
 		return fmt.Errorf("failed to query sources: %v", err)
 	}
@@ -197,7 +198,7 @@ func markProcessedInSever(state bool, id string) error {
 		}
 		defer conn.Close(ctx)
 
-		_, err = conn.Exec(ctx, "UPDATE dwarkesh_tweets SET processed = $1 WHERE tweetid = $2", state, id)
+		_, err = conn.Exec(ctx, "UPDATE tweets SET processed = $1 WHERE tweetid = $2", state, id)
 		if err != nil {
 			log.Printf("failed to mark source as processed: %v", err)
 			// Revert UI state if database update fails
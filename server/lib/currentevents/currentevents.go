@@ -0,0 +1,138 @@
+package currentevents
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+/*
+Parses Wikipedia's Portal:Current_events day pages, e.g.
+https://en.wikipedia.org/wiki/Portal:Current_events/2025_February_10, which
+look like:
+
+	<div class="current-events-content description">
+	  <p><b>Armed conflicts and attacks</b></p>
+	  <ul>
+	    <li><a href="/wiki/...">Russian invasion of Ukraine</a>
+	      <ul>
+	        <li>One line describing the event. <a class="external text" href="...">(Reuters)</a></li>
+	      </ul>
+	    </li>
+	  </ul>
+	  ...
+
+Each bullet without sub-bullets is an event. The bullets it's nested under
+are its topics, and the bold line before the list is its category. Newer
+pages use <div role="heading"> rather than <p><b> for categories.
+*/
+
+type Event struct {
+	Date        time.Time
+	Category    string   // e.g. "Armed conflicts and attacks"
+	Topics      []string // outermost first, e.g. ["Russian invasion of Ukraine", "Attacks on Kyiv"]
+	Description string
+	Links       []string // citations, outside Wikipedia
+}
+
+const portalURL = "https://en.wikipedia.org/wiki/Portal:Current_events/"
+
+// DayURL is the page for a day, e.g. .../Portal:Current_events/2025_February_10
+func DayURL(day time.Time) string {
+	// Not "2006_January_2": "_2" is a space padded day in Go's layouts
+	return portalURL + day.Format("2006_January_") + strconv.Itoa(day.Day())
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func isCitation(link string) bool {
+	return strings.HasPrefix(link, "http") &&
+		!strings.Contains(link, "wikipedia.org") &&
+		!strings.Contains(link, "wikimedia.org") &&
+		!strings.Contains(link, "wikimediafoundation.org")
+}
+
+// bullet splits an <li> into its own text and citations, leaving out
+// anything in its sub-bullets
+func bullet(li *goquery.Selection) (string, []string) {
+	own := li.Clone()
+	own.ChildrenFiltered("ul").Remove()
+
+	var links []string
+	citations := own.Find("a.external")
+	citations.Each(func(_ int, a *goquery.Selection) {
+		href, _ := a.Attr("href")
+		if strings.HasPrefix(href, "//") {
+			href = "https:" + href
+		}
+		if isCitation(href) {
+			links = append(links, href)
+		}
+	})
+	citations.Remove()
+
+	text := collapseSpaces(own.Text())
+	// Citations are often wrapped in brackets of their own
+	text = strings.TrimSpace(strings.TrimSuffix(text, "()"))
+	return text, links
+}
+
+func parseList(ul *goquery.Selection, day time.Time, category string, topics []string) []Event {
+	var events []Event
+	ul.ChildrenFiltered("li").Each(func(_ int, li *goquery.Selection) {
+		text, links := bullet(li)
+		sub_lists := li.ChildrenFiltered("ul")
+		if sub_lists.Length() == 0 || len(links) > 0 {
+			if text != "" {
+				events = append(events, Event{
+					Date:        day,
+					Category:    category,
+					Topics:      append([]string(nil), topics...),
+					Description: text,
+					Links:       links,
+				})
+			}
+		}
+		sub_lists.Each(func(_ int, sub_list *goquery.Selection) {
+			events = append(events, parseList(sub_list, day, category, append(topics[:len(topics):len(topics)], text))...)
+		})
+	})
+	return events
+}
+
+// Parse returns the events on a day page, in page order
+func Parse(page []byte, day time.Time) ([]Event, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	doc.Find(".current-events-content").Each(func(_ int, content *goquery.Selection) {
+		category := ""
+		content.Children().Each(func(_ int, child *goquery.Selection) {
+			if child.Is("ul") {
+				events = append(events, parseList(child, day, category, nil)...)
+			} else if heading := collapseSpaces(child.Text()); heading != "" {
+				category = heading
+			}
+		})
+	})
+	return events, nil
+}
+
+// Path is the category and topics, e.g.
+// "Armed conflicts and attacks > Russian invasion of Ukraine"
+func (e Event) Path() string {
+	var parts []string
+	for _, part := range append([]string{e.Category}, e.Topics...) {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, " > ")
+}
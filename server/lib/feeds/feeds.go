@@ -0,0 +1,209 @@
+package feeds
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/pubdate"
+)
+
+/*
+Parses RSS 2.0, Atom and JSON Feed documents into a common list of items,
+newest first, as lib/feedstate expects them.
+*/
+
+type Item struct {
+	ID      string
+	Title   string
+	Link    string
+	Date    time.Time // zero if the feed didn't give one we could parse
+	Summary string
+}
+
+type rssFeed struct {
+	Items []struct {
+		GUID        string `xml:"guid"`
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		PubDate     string `xml:"pubDate"`
+		DCDate      string `xml:"http://purl.org/dc/elements/1.1/ date"`
+		Description string `xml:"description"`
+	} `xml:"channel>item"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomFeed struct {
+	Entries []struct {
+		ID        string     `xml:"id"`
+		Title     string     `xml:"title"`
+		Links     []atomLink `xml:"link"`
+		Published string     `xml:"published"`
+		Updated   string     `xml:"updated"`
+		Summary   string     `xml:"summary"`
+		Content   string     `xml:"content"`
+	} `xml:"entry"`
+}
+
+type jsonFeed struct {
+	Version string `json:"version"`
+	Items   []struct {
+		ID            any    `json:"id"` // the spec says string, but some feeds use numbers
+		Url           string `json:"url"`
+		ExternalUrl   string `json:"external_url"`
+		Title         string `json:"title"`
+		Summary       string `json:"summary"`
+		ContentText   string `json:"content_text"`
+		DatePublished string `json:"date_published"`
+		DateModified  string `json:"date_modified"`
+	} `json:"items"`
+}
+
+func parseDate(raws ...string) time.Time {
+	for _, raw := range raws {
+		date, err := pubdate.Parse(raw)
+		if err == nil {
+			return date
+		}
+	}
+	return time.Time{}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func parseRSS(body []byte) ([]Item, error) {
+	var feed rssFeed
+	err := xml.Unmarshal(body, &feed)
+	if err != nil {
+		return nil, err
+	}
+	var items []Item
+	for _, i := range feed.Items {
+		items = append(items, Item{
+			ID:      firstNonEmpty(i.GUID, i.Link),
+			Title:   strings.TrimSpace(i.Title),
+			Link:    strings.TrimSpace(i.Link),
+			Date:    parseDate(i.PubDate, i.DCDate),
+			Summary: strings.TrimSpace(i.Description),
+		})
+	}
+	return items, nil
+}
+
+func atomHref(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	if len(links) > 0 {
+		return links[0].Href
+	}
+	return ""
+}
+
+func parseAtom(body []byte) ([]Item, error) {
+	var feed atomFeed
+	err := xml.Unmarshal(body, &feed)
+	if err != nil {
+		return nil, err
+	}
+	var items []Item
+	for _, e := range feed.Entries {
+		link := atomHref(e.Links)
+		items = append(items, Item{
+			ID:      firstNonEmpty(e.ID, link),
+			Title:   strings.TrimSpace(e.Title),
+			Link:    strings.TrimSpace(link),
+			Date:    parseDate(e.Published, e.Updated),
+			Summary: firstNonEmpty(e.Summary, e.Content),
+		})
+	}
+	return items, nil
+}
+
+func parseJSONFeed(body []byte) ([]Item, error) {
+	var feed jsonFeed
+	err := json.Unmarshal(body, &feed)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(feed.Version, "https://jsonfeed.org/version/") {
+		return nil, errors.New("Not a JSON Feed")
+	}
+	var items []Item
+	for _, i := range feed.Items {
+		link := firstNonEmpty(i.Url, i.ExternalUrl)
+		id := ""
+		if i.ID != nil {
+			id = jsonString(i.ID)
+		}
+		items = append(items, Item{
+			ID:      firstNonEmpty(id, link),
+			Title:   strings.TrimSpace(i.Title),
+			Link:    link,
+			Date:    parseDate(i.DatePublished, i.DateModified),
+			Summary: firstNonEmpty(i.Summary, i.ContentText),
+		})
+	}
+	return items, nil
+}
+
+func jsonString(v any) string {
+	s, ok := v.(string)
+	if ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// Parse works out whether body is an RSS, Atom or JSON feed, and returns
+// its items, newest first
+func Parse(body []byte) ([]Item, error) {
+	trimmed := bytes.TrimSpace(body)
+	start := trimmed[:min(len(trimmed), 1024)]
+	var items []Item
+	var err error
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		items, err = parseJSONFeed(trimmed)
+	case bytes.Contains(start, []byte("<feed")):
+		items, err = parseAtom(trimmed)
+	case bytes.Contains(start, []byte("<rss")):
+		items, err = parseRSS(trimmed)
+	default:
+		return nil, errors.New("Unrecognized feed format")
+	}
+	if err != nil {
+		return nil, err
+	}
+	sortNewestFirst(items)
+	return items, nil
+}
+
+// Most feeds are already newest first, but not all. Items without a date
+// keep their place relative to each other, after the dated ones.
+func sortNewestFirst(items []Item) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Date.IsZero() || items[j].Date.IsZero() {
+			return !items[i].Date.IsZero() && items[j].Date.IsZero()
+		}
+		return items[i].Date.After(items[j].Date)
+	})
+}
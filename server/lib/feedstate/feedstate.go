@@ -0,0 +1,178 @@
+package feedstate
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+	"github.com/jackc/pgx/v5"
+)
+
+// What we remember about a polled feed between cycles
+type FeedState struct {
+	Url          string
+	ETag         string
+	LastModified string
+	// Where sources which page by id, e.g. Telegram, read on from
+	LastEntryID string
+	// Entries still in the feed which we're done with: processed, or
+	// given up on after MaxAttempts failures
+	DoneEntryIDs []string
+	// Failed attempts at the entries we aren't done with yet
+	Attempts map[string]int
+
+	// For monitoring. A 304 counts as a success.
+	LastSuccessAt time.Time
+	NumItems      int   // in the feed when we last parsed it
+	NumNewItems   int64 // ever seen, in total
+}
+
+func Load(feed_url string, database_url string) (FeedState, error) {
+	state := FeedState{Url: feed_url, Attempts: map[string]int{}}
+	conn, err := pgx.Connect(context.Background(), database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return state, err
+	}
+	defer conn.Close(context.Background())
+
+	var last_success_at *time.Time
+	err = conn.QueryRow(context.Background(), `
+		SELECT etag, last_modified, last_entry_id, done_entry_ids, entry_attempts, last_success_at, num_items, num_new_items
+		FROM feed_state WHERE url = $1
+	`, feed_url).Scan(&state.ETag, &state.LastModified, &state.LastEntryID, &state.DoneEntryIDs, &state.Attempts, &last_success_at, &state.NumItems, &state.NumNewItems)
+	if err == pgx.ErrNoRows {
+		return state, nil
+	}
+	if err != nil {
+		log.Printf("Error loading feed state: %v\n", err)
+		return state, err
+	}
+	if last_success_at != nil {
+		state.LastSuccessAt = *last_success_at
+	}
+	return state, nil
+}
+
+func Save(state FeedState, database_url string) error {
+	conn, err := pgx.Connect(context.Background(), database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	var last_success_at *time.Time
+	if !state.LastSuccessAt.IsZero() {
+		last_success_at = &state.LastSuccessAt
+	}
+	done_entry_ids := state.DoneEntryIDs
+	if done_entry_ids == nil {
+		done_entry_ids = []string{}
+	}
+	attempts := state.Attempts
+	if attempts == nil {
+		attempts = map[string]int{}
+	}
+	_, err = conn.Exec(context.Background(), `
+		INSERT INTO feed_state (url, etag, last_modified, last_entry_id, done_entry_ids, entry_attempts, last_success_at, num_items, num_new_items, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, CURRENT_TIMESTAMP)
+		ON CONFLICT (url) DO UPDATE SET
+			etag = EXCLUDED.etag,
+			last_modified = EXCLUDED.last_modified,
+			last_entry_id = EXCLUDED.last_entry_id,
+			done_entry_ids = EXCLUDED.done_entry_ids,
+			entry_attempts = EXCLUDED.entry_attempts,
+			last_success_at = EXCLUDED.last_success_at,
+			num_items = EXCLUDED.num_items,
+			num_new_items = EXCLUDED.num_new_items,
+			updated_at = CURRENT_TIMESTAMP
+	`, state.Url, state.ETag, state.LastModified, state.LastEntryID, done_entry_ids, attempts, last_success_at, state.NumItems, state.NumNewItems)
+	if err != nil {
+		log.Printf("Error saving feed state: %v\n", err)
+		return err
+	}
+	return nil
+}
+
+// Fetch makes a conditional request for the feed. If the server says it
+// hasn't changed, body is nil and unchanged is true. A missing or broken
+// state store degrades to an unconditional fetch.
+func Fetch(feed_url string, database_url string) (body []byte, state FeedState, unchanged bool, err error) {
+	state, err = Load(feed_url, database_url)
+	if err != nil {
+		state = FeedState{Url: feed_url}
+	}
+	resp, err := web.GetConditional(feed_url, state.ETag, state.LastModified)
+	if err != nil {
+		return nil, state, false, err
+	}
+	state.LastSuccessAt = time.Now()
+	if resp.NotModified {
+		log.Printf("Feed unchanged: %v", feed_url)
+		Save(state, database_url)
+		return nil, state, true, nil
+	}
+	state.ETag = resp.ETag
+	state.LastModified = resp.LastModified
+	return resp.Body, state, false, nil
+}
+
+// How many cycles an entry can fail in before we give up on it, so that
+// one which always fails doesn't have the ones around it, or itself, sent
+// to be summarized and scored again every cycle
+const MaxAttempts = 3
+
+// NewEntries returns which of entry_ids, the entries now in the feed, we
+// aren't done with yet. Done entries which have fallen off the feed are
+// forgotten.
+func NewEntries(state FeedState, entry_ids []string) (FeedState, map[string]bool) {
+	in_feed := map[string]bool{}
+	for _, id := range entry_ids {
+		in_feed[id] = true
+	}
+	done := map[string]bool{}
+	var done_entry_ids []string
+	for _, id := range state.DoneEntryIDs {
+		if in_feed[id] {
+			done[id] = true
+			done_entry_ids = append(done_entry_ids, id)
+		}
+	}
+	state.DoneEntryIDs = done_entry_ids
+
+	new_entries := map[string]bool{}
+	for _, id := range entry_ids {
+		if !done[id] {
+			new_entries[id] = true
+		}
+	}
+	return state, new_entries
+}
+
+// Advance records that new_entry_ids have been processed, except for those
+// in failed. Those are tried again next cycle, until they have failed
+// MaxAttempts times, and while any are left the ETag and Last-Modified
+// are dropped, so that the retry isn't answered with a 304.
+func Advance(state FeedState, new_entry_ids []string, failed map[string]bool) FeedState {
+	attempts := map[string]int{}
+	for _, id := range new_entry_ids {
+		if failed[id] {
+			attempts[id] = state.Attempts[id] + 1
+			if attempts[id] < MaxAttempts {
+				continue
+			}
+			log.Printf("Giving up on %v after %d failed attempts", id, attempts[id])
+			delete(attempts, id)
+		}
+		state.DoneEntryIDs = append(state.DoneEntryIDs, id)
+		state.NumNewItems++
+	}
+	state.Attempts = attempts
+	if len(attempts) > 0 {
+		state.ETag = ""
+		state.LastModified = ""
+	}
+	return state
+}
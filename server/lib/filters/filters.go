@@ -2,11 +2,11 @@ package filters
 
 import (
 	"context"
+	"git.nunosempere.com/NunoSempere/news/lib/hosts"
 	"git.nunosempere.com/NunoSempere/news/lib/types"
 	"github.com/jackc/pgx/v5"
 	"log"
 	"net/url"
-	"slices"
 	"strings"
 )
 
@@ -19,10 +19,12 @@ func IsDupe(source types.Source, database_url string) bool {
 	defer conn.Close(context.Background())
 
 	var exists bool
+	// An empty title, e.g. a GDELT event's before we read its page, would
+	// match every other untitled row, so those are checked on their link
 	err = conn.QueryRow(context.Background(), `
 		SELECT EXISTS(
 			SELECT 1 FROM sources 
-			WHERE UPPER(title) = $1 OR link = $2
+			WHERE (UPPER(title) = $1 AND $1 <> '') OR link = $2
 		)
 	`, source.Title, source.Link).Scan(&exists)
 	if err != nil {
@@ -39,13 +41,13 @@ func IsDupe(source types.Source, database_url string) bool {
 }
 
 func IsGoodHost(source types.Source) bool {
-	parsedURL, err := url.Parse(source.Link)
+	_, err := url.Parse(source.Link)
 	if err != nil {
 		log.Printf("Error parsing link: %v", err)
 		return false
 	}
-	skippable_hosts := []string{"www.washingtonpost.com", "www.youtube.com", "www.naturalnews.com", "facebook.com", "m.facebook.com"}
-	is_bad_host := slices.Contains(skippable_hosts, parsedURL.Host)
+	// Hosts to skip are configured in config/hosts.yaml
+	is_bad_host := hosts.IsSkipped(source.Link)
 	if is_bad_host {
 		log.Printf("Article is from a bad host")
 	} else {
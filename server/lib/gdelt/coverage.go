@@ -0,0 +1,190 @@
+package gdelt
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+/*
+How widely an event is being reported. Each 15 minute mentions file is
+aggregated into a mention count and the set of sources per event, which
+are kept in gdelt_event_coverage so that they add up across windows. Every
+event is stored, even if only one source mentions it in a window: a story
+which a different outlet picks up every 15 minutes is still spreading.
+PruneCoverage keeps the table to the last few days.
+
+Urls are linked to the events they mention within a window, so that GKG
+articles, which don't carry event ids, can be looked up too.
+*/
+
+// An event needs this many sources in the last hour to count as rising
+const minSourcesToRise = 2
+
+type EventCoverage struct {
+	NumMentions int
+	Sources     map[string]bool
+}
+
+type Window struct {
+	Start       time.Time
+	ByEvent     map[int64]*EventCoverage
+	EventsByURL map[string][]int64
+}
+
+// Aggregate tallies a mentions file by event and by url
+func Aggregate(mentions []Mention) Window {
+	window := Window{ByEvent: map[int64]*EventCoverage{}, EventsByURL: map[string][]int64{}}
+	for _, mention := range mentions {
+		if window.Start.IsZero() {
+			window.Start = mention.MentionTimeDate
+		}
+		coverage, exists := window.ByEvent[mention.GlobalEventID]
+		if !exists {
+			coverage = &EventCoverage{Sources: map[string]bool{}}
+			window.ByEvent[mention.GlobalEventID] = coverage
+		}
+		coverage.NumMentions++
+		coverage.Sources[mention.MentionSourceName] = true
+		if mention.MentionType == MentionWeb {
+			window.EventsByURL[mention.MentionIdentifier] = append(window.EventsByURL[mention.MentionIdentifier], mention.GlobalEventID)
+		}
+	}
+	return window
+}
+
+// EventsFor returns the events an article mentions. Event source urls are
+// also the first article that mentioned them, so they show up here too.
+func (w Window) EventsFor(url string) []int64 {
+	return w.EventsByURL[url]
+}
+
+func SaveWindow(window Window, database_url string) error {
+	conn, err := pgx.Connect(context.Background(), database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	batch := &pgx.Batch{}
+	for event_id, coverage := range window.ByEvent {
+		sources := make([]string, 0, len(coverage.Sources))
+		for source := range coverage.Sources {
+			sources = append(sources, source)
+		}
+		sort.Strings(sources)
+		batch.Queue(`
+			INSERT INTO gdelt_event_coverage (global_event_id, window_start, num_mentions, num_sources, sources)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (global_event_id, window_start) DO UPDATE SET
+				num_mentions = EXCLUDED.num_mentions,
+				num_sources = EXCLUDED.num_sources,
+				sources = EXCLUDED.sources
+		`, event_id, window.Start, coverage.NumMentions, len(coverage.Sources), sources)
+	}
+	if batch.Len() == 0 {
+		return nil
+	}
+	err = conn.SendBatch(context.Background(), batch).Close()
+	if err != nil {
+		log.Printf("Error saving gdelt coverage: %v", err)
+		return err
+	}
+	log.Printf("Saved coverage for %d events", batch.Len())
+	return nil
+}
+
+type Coverage struct {
+	NumSources  int // distinct sources across windows
+	NumMentions int
+	// Sources in the last hour, and in the hour before that
+	RecentSources   int
+	PreviousSources int
+}
+
+func (c Coverage) Rising() bool {
+	return c.RecentSources > c.PreviousSources && c.RecentSources >= minSourcesToRise
+}
+
+// GetCoverages returns, for each list of event ids, e.g. the events an
+// article mentions, the coverage of the most widely reported of them. It
+// uses a single connection, as it's called for a whole batch of articles.
+func GetCoverages(event_ids [][]int64, database_url string) ([]Coverage, error) {
+	coverages := make([]Coverage, len(event_ids))
+	conn, err := pgx.Connect(context.Background(), database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return coverages, err
+	}
+	defer conn.Close(context.Background())
+
+	for i, ids := range event_ids {
+		coverages[i], err = getCoverage(conn, ids)
+		if err != nil {
+			return coverages, err
+		}
+	}
+	return coverages, nil
+}
+
+func getCoverage(conn *pgx.Conn, event_ids []int64) (Coverage, error) {
+	var coverage Coverage
+	if len(event_ids) == 0 {
+		return coverage, nil
+	}
+	// An outlet which reports on an event in several windows is counted once
+	err := conn.QueryRow(context.Background(), `
+		WITH latest AS (SELECT MAX(window_start) AS window_start FROM gdelt_event_coverage),
+		mentions AS (
+			SELECT global_event_id, SUM(num_mentions) AS num_mentions
+			FROM gdelt_event_coverage
+			WHERE global_event_id = ANY($1)
+			GROUP BY global_event_id
+		),
+		sources AS (
+			SELECT
+				c.global_event_id,
+				COUNT(DISTINCT s.source) AS num_sources,
+				COUNT(DISTINCT s.source) FILTER (WHERE c.window_start > latest.window_start - INTERVAL '1 hour') AS recent_sources,
+				COUNT(DISTINCT s.source) FILTER (WHERE c.window_start <= latest.window_start - INTERVAL '1 hour'
+					AND c.window_start > latest.window_start - INTERVAL '2 hours') AS previous_sources
+			FROM gdelt_event_coverage c CROSS JOIN latest CROSS JOIN unnest(c.sources) AS s(source)
+			WHERE c.global_event_id = ANY($1)
+			GROUP BY c.global_event_id
+		)
+		SELECT s.num_sources, m.num_mentions, s.recent_sources, s.previous_sources
+		FROM sources s JOIN mentions m USING (global_event_id)
+		ORDER BY s.num_sources DESC
+		LIMIT 1
+	`, event_ids).Scan(&coverage.NumSources, &coverage.NumMentions, &coverage.RecentSources, &coverage.PreviousSources)
+	if err == pgx.ErrNoRows {
+		return coverage, nil
+	}
+	if err != nil {
+		log.Printf("Error getting gdelt coverage: %v", err)
+		return coverage, err
+	}
+	return coverage, nil
+}
+
+func PruneCoverage(days int, database_url string) (int64, error) {
+	conn, err := pgx.Connect(context.Background(), database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return 0, err
+	}
+	defer conn.Close(context.Background())
+
+	tag, err := conn.Exec(context.Background(), `
+		DELETE FROM gdelt_event_coverage WHERE window_start < NOW() - make_interval(days => $1)
+	`, days)
+	if err != nil {
+		log.Printf("Error pruning gdelt coverage: %v", err)
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,212 @@
+package gdelt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Parses the events export, one event per line, 61 tab-separated fields. See
+guides/gdelt/schemas/gdelt-schema.txt and the Event Codebook for what each
+field means.
+*/
+
+const (
+	VerbalCooperation   = 1
+	MaterialCooperation = 2
+	VerbalConflict      = 3
+	MaterialConflict    = 4
+)
+
+type Actor struct {
+	Code          string
+	Name          string
+	CountryCode   string
+	KnownGroup    string
+	EthnicCode    string
+	Religion1Code string
+	Religion2Code string
+	Type1Code     string
+	Type2Code     string
+	Type3Code     string
+}
+
+type Geo struct {
+	Type        int
+	Fullname    string
+	CountryCode string
+	ADM1Code    string
+	ADM2Code    string
+	Lat         float64
+	Long        float64
+	FeatureID   string
+}
+
+type Event struct {
+	GlobalEventID  int64
+	Day            time.Time
+	MonthYear      int
+	Year           int
+	FractionDate   float64
+	Actor1         Actor
+	Actor2         Actor
+	IsRootEvent    bool
+	EventCode      string
+	EventBaseCode  string
+	EventRootCode  string
+	QuadClass      int
+	GoldsteinScale float64
+	NumMentions    int
+	NumSources     int
+	NumArticles    int
+	AvgTone        float64
+	Actor1Geo      Geo
+	Actor2Geo      Geo
+	ActionGeo      Geo
+	DateAdded      time.Time
+	SourceURL      string
+}
+
+const numEventFields = 61
+
+// Names of the CAMEO root codes, for describing events to people and LLMs
+var EventRootNames = map[string]string{
+	"01": "Make public statement",
+	"02": "Appeal",
+	"03": "Express intent to cooperate",
+	"04": "Consult",
+	"05": "Engage in diplomatic cooperation",
+	"06": "Engage in material cooperation",
+	"07": "Provide aid",
+	"08": "Yield",
+	"09": "Investigate",
+	"10": "Demand",
+	"11": "Disapprove",
+	"12": "Reject",
+	"13": "Threaten",
+	"14": "Protest",
+	"15": "Exhibit force posture",
+	"16": "Reduce relations",
+	"17": "Coerce",
+	"18": "Assault",
+	"19": "Fight",
+	"20": "Use unconventional mass violence",
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(s))
+	return n
+}
+
+func atof(s string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return f
+}
+
+func parseActor(fields []string) Actor {
+	return Actor{
+		Code:          fields[0],
+		Name:          fields[1],
+		CountryCode:   fields[2],
+		KnownGroup:    fields[3],
+		EthnicCode:    fields[4],
+		Religion1Code: fields[5],
+		Religion2Code: fields[6],
+		Type1Code:     fields[7],
+		Type2Code:     fields[8],
+		Type3Code:     fields[9],
+	}
+}
+
+func parseGeo(fields []string) Geo {
+	return Geo{
+		Type:        atoi(fields[0]),
+		Fullname:    fields[1],
+		CountryCode: fields[2],
+		ADM1Code:    fields[3],
+		ADM2Code:    fields[4],
+		Lat:         atof(fields[5]),
+		Long:        atof(fields[6]),
+		FeatureID:   fields[7],
+	}
+}
+
+func ParseEvent(line string) (Event, error) {
+	fields := strings.Split(line, "\t")
+	if len(fields) < numEventFields {
+		return Event{}, fmt.Errorf("Expected %d fields in event, got: %d", numEventFields, len(fields))
+	}
+	id, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return Event{}, fmt.Errorf("Invalid GlobalEventID %q: %w", fields[0], err)
+	}
+	day, _ := time.Parse("20060102", fields[1])
+	date_added, _ := time.Parse("20060102150405", fields[59])
+	return Event{
+		GlobalEventID:  id,
+		Day:            day,
+		MonthYear:      atoi(fields[2]),
+		Year:           atoi(fields[3]),
+		FractionDate:   atof(fields[4]),
+		Actor1:         parseActor(fields[5:15]),
+		Actor2:         parseActor(fields[15:25]),
+		IsRootEvent:    fields[25] == "1",
+		EventCode:      fields[26],
+		EventBaseCode:  fields[27],
+		EventRootCode:  fields[28],
+		QuadClass:      atoi(fields[29]),
+		GoldsteinScale: atof(fields[30]),
+		NumMentions:    atoi(fields[31]),
+		NumSources:     atoi(fields[32]),
+		NumArticles:    atoi(fields[33]),
+		AvgTone:        atof(fields[34]),
+		Actor1Geo:      parseGeo(fields[35:43]),
+		Actor2Geo:      parseGeo(fields[43:51]),
+		ActionGeo:      parseGeo(fields[51:59]),
+		DateAdded:      date_added,
+		SourceURL:      strings.TrimSpace(fields[60]),
+	}, nil
+}
+
+// ParseEvents reads an events export, skipping lines it can't parse
+func ParseEvents(r io.Reader) ([]Event, error) {
+	var events []Event
+	scanner := bufio.NewScanner(r)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 1024*1024)
+	for scanner.Scan() {
+		event, err := ParseEvent(scanner.Text())
+		if err != nil {
+			log.Printf("Skipping event: %v", err)
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
+
+// Describe summarizes an event's metadata in a line, e.g.
+// "USA (United States) → RUS (Russia): Fight (190), Goldstein -10.0, 12 sources, 30 articles, in Kyiv, Ukraine"
+func (e Event) Describe() string {
+	actor := func(a Actor) string {
+		if a.Name == "" {
+			return "unknown"
+		}
+		if a.CountryCode == "" {
+			return a.Name
+		}
+		return a.CountryCode + " (" + a.Name + ")"
+	}
+	description := fmt.Sprintf("%s → %s: %s (%s), Goldstein %.1f, %d sources, %d articles",
+		actor(e.Actor1), actor(e.Actor2), EventRootNames[e.EventRootCode], e.EventCode,
+		e.GoldsteinScale, e.NumSources, e.NumArticles)
+	if e.ActionGeo.Fullname != "" {
+		description += ", in " + e.ActionGeo.Fullname
+	}
+	return description
+}
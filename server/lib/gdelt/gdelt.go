@@ -0,0 +1,235 @@
+package gdelt
+
+import (
+	"archive/zip"
+	"bufio"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+GDELT 2.0 publishes three zipped, tab-separated files every 15 minutes: the
+events export, the mentions of those events, and the Global Knowledge Graph.
+lastupdate.txt lists the latest of each, one per line, as "size hash url".
+See guides/gdelt for the codebooks.
+
+Non-English press is machine translated and published as a separate,
+parallel stream, e.g. 20240328160000.translation.gkg.csv.zip, listed in
+lastupdate-translation.txt.
+*/
+
+const Interval = 15 * time.Minute
+
+type Stream struct {
+	Name              string
+	LastUpdateURL     string
+	MasterFileListURL string
+}
+
+var (
+	English = Stream{
+		Name:              "english",
+		LastUpdateURL:     "http://data.gdeltproject.org/gdeltv2/lastupdate.txt",
+		MasterFileListURL: "http://data.gdeltproject.org/gdeltv2/masterfilelist.txt",
+	}
+	Translingual = Stream{
+		Name:              "translingual",
+		LastUpdateURL:     "http://data.gdeltproject.org/gdeltv2/lastupdate-translation.txt",
+		MasterFileListURL: "http://data.gdeltproject.org/gdeltv2/masterfilelist-translation.txt",
+	}
+)
+
+// The three files for a 15 minute interval
+type Update struct {
+	Stream      Stream
+	Timestamp   time.Time
+	ExportURL   string
+	MentionsURL string
+	GKGURL      string
+}
+
+func (u Update) IsComplete() bool {
+	return u.ExportURL != "" && u.MentionsURL != "" && u.GKGURL != ""
+}
+
+// Files are named after their interval, e.g. 20250210151500.gkg.csv.zip
+func timestampOf(url string) (time.Time, bool) {
+	name := path.Base(url)
+	if len(name) < 14 {
+		return time.Time{}, false
+	}
+	timestamp, err := time.Parse("20060102150405", name[:14])
+	return timestamp, err == nil
+}
+
+// addFile records a "size hash url" line in the update for its interval.
+// The suffixes match both streams, e.g. .translation.gkg.csv.zip
+func addFile(stream Stream, updates map[time.Time]*Update, line string) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return
+	}
+	url := fields[2]
+	timestamp, ok := timestampOf(url)
+	if !ok {
+		return
+	}
+	update, exists := updates[timestamp]
+	if !exists {
+		update = &Update{Stream: stream, Timestamp: timestamp}
+		updates[timestamp] = update
+	}
+	switch {
+	case strings.HasSuffix(url, ".export.CSV.zip"):
+		update.ExportURL = url
+	case strings.HasSuffix(url, ".mentions.CSV.zip"):
+		update.MentionsURL = url
+	case strings.HasSuffix(url, ".gkg.csv.zip"):
+		update.GKGURL = url
+	}
+}
+
+func LastUpdate(stream Stream) (Update, error) {
+	name := path.Base(stream.LastUpdateURL)
+	resp, err := http.Get(stream.LastUpdateURL)
+	if err != nil {
+		return Update{}, fmt.Errorf("fetching %v: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	updates := map[time.Time]*Update{}
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		addFile(stream, updates, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return Update{}, fmt.Errorf("reading %v: %w", name, err)
+	}
+	for _, update := range updates {
+		if update.IsComplete() {
+			return *update, nil
+		}
+	}
+	return Update{}, fmt.Errorf("%v doesn't have the three expected files", name)
+}
+
+// Intervals walks the stream's masterfilelist.txt, which lists every file
+// GDELT 2.0 has ever published, and returns the intervals strictly between
+// since and until, oldest first. Intervals missing one of their files are
+// skipped.
+func Intervals(stream Stream, since time.Time, until time.Time) ([]Update, error) {
+	name := path.Base(stream.MasterFileListURL)
+	resp, err := http.Get(stream.MasterFileListURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %v: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	updates := map[time.Time]*Update{}
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Cheap check before parsing: the list is years long
+		i := strings.LastIndex(line, "/")
+		if i == -1 || len(line) < i+15 || line[i+1:i+15] <= since.Format("20060102150405") {
+			continue
+		}
+		addFile(stream, updates, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %v: %w", name, err)
+	}
+
+	var intervals []Update
+	for timestamp, update := range updates {
+		if !timestamp.Before(until) {
+			continue
+		}
+		if !update.IsComplete() {
+			log.Printf("Skipping incomplete %v GDELT interval %v", stream.Name, timestamp)
+			continue
+		}
+		intervals = append(intervals, *update)
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].Timestamp.Before(intervals[j].Timestamp)
+	})
+	return intervals, nil
+}
+
+// zipEntry is the csv inside a zip being streamed over http. Closing it
+// closes the response.
+type zipEntry struct {
+	io.Reader
+	body io.Closer
+}
+
+func (z zipEntry) Close() error {
+	return z.body.Close()
+}
+
+// DownloadZip fetches one of GDELT's zipfiles, and decompresses the csv
+// inside it as it downloads, so that the file is never all in memory.
+// GDELT's zips hold a single file, which comes first, so we can read it
+// from its local header rather than from the central directory at the end.
+func DownloadZip(url string) (io.ReadCloser, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("downloading file: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("downloading %v: http status %v", url, resp.StatusCode)
+	}
+
+	body := bufio.NewReaderSize(resp.Body, 1024*1024)
+	entry, err := openFirstEntry(body)
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("Error reading zip content from %v: %w", url, err)
+	}
+	return zipEntry{Reader: entry, body: resp.Body}, nil
+}
+
+// openFirstEntry reads the local file header at the start of a zip, and
+// returns a reader for that file's contents. See section 4.3.7 of
+// https://pkware.cachefly.net/webdocs/casestudies/APPNOTE.TXT
+func openFirstEntry(r io.Reader) (io.Reader, error) {
+	var header [30]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("reading zip header: %w", err)
+	}
+	if binary.LittleEndian.Uint32(header[0:4]) != 0x04034b50 {
+		return nil, fmt.Errorf("Zip file is empty or not a zip file")
+	}
+	flags := binary.LittleEndian.Uint16(header[6:8])
+	method := binary.LittleEndian.Uint16(header[8:10])
+	compressed_size := binary.LittleEndian.Uint32(header[18:22])
+	name_length := binary.LittleEndian.Uint16(header[26:28])
+	extra_length := binary.LittleEndian.Uint16(header[28:30])
+	if _, err := io.CopyN(io.Discard, r, int64(name_length)+int64(extra_length)); err != nil {
+		return nil, fmt.Errorf("reading zip header: %w", err)
+	}
+
+	switch method {
+	case zip.Deflate:
+		// Deflate streams mark their own end, so this works even when the
+		// sizes are only given after the data
+		return flate.NewReader(r), nil
+	case zip.Store:
+		if flags&0x8 != 0 {
+			return nil, fmt.Errorf("can't stream an uncompressed zip entry without its size")
+		}
+		return io.LimitReader(r, int64(compressed_size)), nil
+	default:
+		return nil, fmt.Errorf("unsupported zip compression method %d", method)
+	}
+}
@@ -0,0 +1,88 @@
+package gdelt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Parses the mentions file: one row per article mentioning an event, whether
+the event is new in this 15 minute window or was first seen earlier. See
+the Mentions table in the Event Codebook.
+*/
+
+const (
+	MentionWeb = 1 // MentionIdentifier is a url
+)
+
+type Mention struct {
+	GlobalEventID      int64
+	EventTimeDate      time.Time
+	MentionTimeDate    time.Time
+	MentionType        int
+	MentionSourceName  string
+	MentionIdentifier  string
+	SentenceID         int
+	Actor1CharOffset   int
+	Actor2CharOffset   int
+	ActionCharOffset   int
+	InRawText          bool
+	Confidence         int
+	MentionDocLen      int
+	MentionDocTone     float64
+	DocTranslationInfo string
+}
+
+const numMentionFields = 15
+
+func ParseMention(line string) (Mention, error) {
+	fields := strings.Split(line, "\t")
+	if len(fields) < numMentionFields {
+		return Mention{}, fmt.Errorf("Expected %d fields in mention, got: %d", numMentionFields, len(fields))
+	}
+	id, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return Mention{}, fmt.Errorf("Invalid GlobalEventID %q: %w", fields[0], err)
+	}
+	event_time, _ := time.Parse("20060102150405", fields[1])
+	mention_time, _ := time.Parse("20060102150405", fields[2])
+	return Mention{
+		GlobalEventID:      id,
+		EventTimeDate:      event_time,
+		MentionTimeDate:    mention_time,
+		MentionType:        atoi(fields[3]),
+		MentionSourceName:  fields[4],
+		MentionIdentifier:  strings.TrimSpace(fields[5]),
+		SentenceID:         atoi(fields[6]),
+		Actor1CharOffset:   atoi(fields[7]),
+		Actor2CharOffset:   atoi(fields[8]),
+		ActionCharOffset:   atoi(fields[9]),
+		InRawText:          fields[10] == "1",
+		Confidence:         atoi(fields[11]),
+		MentionDocLen:      atoi(fields[12]),
+		MentionDocTone:     atof(fields[13]),
+		DocTranslationInfo: fields[14],
+	}, nil
+}
+
+// ParseMentions reads a mentions file, skipping lines it can't parse
+func ParseMentions(r io.Reader) ([]Mention, error) {
+	var mentions []Mention
+	scanner := bufio.NewScanner(r)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 1024*1024)
+	for scanner.Scan() {
+		mention, err := ParseMention(scanner.Text())
+		if err != nil {
+			log.Printf("Skipping mention: %v", err)
+			continue
+		}
+		mentions = append(mentions, mention)
+	}
+	return mentions, scanner.Err()
+}
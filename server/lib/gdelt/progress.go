@@ -0,0 +1,63 @@
+package gdelt
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// The files of an interval whose progress is kept separately, so that one
+// failing doesn't make the other be processed again
+const (
+	GKGPart    = "gkg"
+	EventsPart = "events"
+)
+
+// LoadProgress returns the last interval of the stream for which we
+// finished processing the given part, or the zero time if we haven't
+// processed any
+func LoadProgress(stream Stream, part string, database_url string) (time.Time, error) {
+	var last_processed time.Time
+	conn, err := pgx.Connect(context.Background(), database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return last_processed, err
+	}
+	defer conn.Close(context.Background())
+
+	err = conn.QueryRow(context.Background(), `
+		SELECT last_processed FROM gdelt_progress WHERE stream = $1 AND part = $2
+	`, stream.Name, part).Scan(&last_processed)
+	if err == pgx.ErrNoRows {
+		return last_processed, nil
+	}
+	if err != nil {
+		log.Printf("Error loading gdelt progress: %v\n", err)
+		return last_processed, err
+	}
+	return last_processed, nil
+}
+
+func SaveProgress(stream Stream, part string, last_processed time.Time, database_url string) error {
+	conn, err := pgx.Connect(context.Background(), database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(context.Background(), `
+		INSERT INTO gdelt_progress (stream, part, last_processed, updated_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (stream, part) DO UPDATE SET
+			last_processed = EXCLUDED.last_processed,
+			updated_at = CURRENT_TIMESTAMP
+	`, stream.Name, part, last_processed)
+	if err != nil {
+		log.Printf("Error saving gdelt progress: %v\n", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,425 @@
+package gkg
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"iter"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Parses GDELT Global Knowledge Graph 2.1 files: one article per line, 27
+tab-separated fields, many of them lists delimited by ";" with subfields
+delimited by "#" or ",". See
+guides/gdelt/GDELT-Global_Knowledge_Graph_Codebook-V2.1.pdf.
+
+Empty fields parse to empty values. Malformed list entries are skipped
+rather than failing the whole record.
+*/
+
+const NumFields = 27
+
+type Location struct {
+	Type        int // 1 country, 2 US state, 3 US city, 4 world city, 5 world state
+	FullName    string
+	CountryCode string
+	ADM1Code    string
+	ADM2Code    string // enhanced locations only
+	Lat         float64
+	Long        float64
+	FeatureID   string
+	CharOffset  int // enhanced locations only
+}
+
+type Count struct {
+	Type       string // e.g. KILL, WOUND, ARREST, KIDNAP, AFFECT, EVACUATE
+	Number     int
+	ObjectType string
+	Location   Location
+	CharOffset int // V2.1 counts only
+}
+
+type Mention struct {
+	Name       string
+	CharOffset int
+}
+
+type Tone struct {
+	Tone                      float64
+	PositiveScore             float64
+	NegativeScore             float64
+	Polarity                  float64
+	ActivityReferenceDensity  float64
+	SelfGroupReferenceDensity float64
+	WordCount                 int
+}
+
+type Date struct {
+	Resolution int // 1 year, 2 month, 3 day, 4 month and day without year
+	Month      int
+	Day        int
+	Year       int
+	CharOffset int
+}
+
+type Quotation struct {
+	CharOffset int
+	Length     int
+	Verb       string
+	Quote      string
+}
+
+type Amount struct {
+	Amount     float64
+	Object     string
+	CharOffset int
+}
+
+type Record struct {
+	RecordID                   string // e.g. 20250210151500-42, or 20250210151500-T42 when translated
+	Date                       time.Time
+	SourceCollectionIdentifier int // 1 web, 2 citation only, 3 CORE, 4 DTIC, 5 JSTOR, 6 non-textual
+	SourceCommonName           string
+	DocumentIdentifier         string // the url, for web sources
+	Counts                     []Count
+	CountsV21                  []Count
+	Themes                     []string
+	EnhancedThemes             []Mention
+	Locations                  []Location
+	EnhancedLocations          []Location
+	Persons                    []string
+	EnhancedPersons            []Mention
+	Organizations              []string
+	EnhancedOrganizations      []Mention
+	Tone                       Tone
+	EnhancedDates              []Date
+	GCAM                       map[string]float64 // e.g. "wc" word count, "c2.21", "v10.1"
+	SharingImage               string
+	RelatedImages              []string
+	SocialImageEmbeds          []string
+	SocialVideoEmbeds          []string
+	Quotations                 []Quotation
+	AllNames                   []Mention
+	Amounts                    []Amount
+	TranslationInfo            string
+	Extras                     string
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(s))
+	return n
+}
+
+func atof(s string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return f
+}
+
+// splitList splits a delimited list field, dropping empty entries
+func splitList(field string, delimiter string) []string {
+	var items []string
+	for _, item := range strings.Split(field, delimiter) {
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Counts: CountType#Number#ObjectType#LocationType#FullName#CountryCode#ADM1Code#Lat#Long#FeatureID[#CharOffset]
+func parseCounts(field string, has_offset bool) []Count {
+	var counts []Count
+	for _, block := range splitList(field, ";") {
+		parts := strings.Split(block, "#")
+		if len(parts) < 10 {
+			continue
+		}
+		count := Count{
+			Type:       parts[0],
+			Number:     atoi(parts[1]),
+			ObjectType: parts[2],
+			Location: Location{
+				Type:        atoi(parts[3]),
+				FullName:    parts[4],
+				CountryCode: parts[5],
+				ADM1Code:    parts[6],
+				Lat:         atof(parts[7]),
+				Long:        atof(parts[8]),
+				FeatureID:   parts[9],
+			},
+		}
+		if has_offset && len(parts) > 10 {
+			count.CharOffset = atoi(parts[10])
+		}
+		counts = append(counts, count)
+	}
+	return counts
+}
+
+// V1 locations: Type#FullName#CountryCode#ADM1Code#Lat#Long#FeatureID
+func parseLocations(field string) []Location {
+	var locations []Location
+	for _, block := range splitList(field, ";") {
+		parts := strings.Split(block, "#")
+		if len(parts) < 7 {
+			continue
+		}
+		locations = append(locations, Location{
+			Type:        atoi(parts[0]),
+			FullName:    parts[1],
+			CountryCode: parts[2],
+			ADM1Code:    parts[3],
+			Lat:         atof(parts[4]),
+			Long:        atof(parts[5]),
+			FeatureID:   parts[6],
+		})
+	}
+	return locations
+}
+
+// Enhanced locations: Type#FullName#CountryCode#ADM1Code#ADM2Code#Lat#Long#FeatureID#CharOffset
+func parseEnhancedLocations(field string) []Location {
+	var locations []Location
+	for _, block := range splitList(field, ";") {
+		parts := strings.Split(block, "#")
+		if len(parts) < 9 {
+			continue
+		}
+		locations = append(locations, Location{
+			Type:        atoi(parts[0]),
+			FullName:    parts[1],
+			CountryCode: parts[2],
+			ADM1Code:    parts[3],
+			ADM2Code:    parts[4],
+			Lat:         atof(parts[5]),
+			Long:        atof(parts[6]),
+			FeatureID:   parts[7],
+			CharOffset:  atoi(parts[8]),
+		})
+	}
+	return locations
+}
+
+// Enhanced themes, persons, organizations and all names: Name,CharOffset
+func parseMentions(field string) []Mention {
+	var mentions []Mention
+	for _, block := range splitList(field, ";") {
+		i := strings.LastIndex(block, ",")
+		if i == -1 {
+			continue
+		}
+		mentions = append(mentions, Mention{Name: block[:i], CharOffset: atoi(block[i+1:])})
+	}
+	return mentions
+}
+
+// Tone,PositiveScore,NegativeScore,Polarity,ActivityReferenceDensity,SelfGroupReferenceDensity,WordCount
+func parseTone(field string) Tone {
+	parts := strings.Split(field, ",")
+	if len(parts) < 7 {
+		return Tone{}
+	}
+	return Tone{
+		Tone:                      atof(parts[0]),
+		PositiveScore:             atof(parts[1]),
+		NegativeScore:             atof(parts[2]),
+		Polarity:                  atof(parts[3]),
+		ActivityReferenceDensity:  atof(parts[4]),
+		SelfGroupReferenceDensity: atof(parts[5]),
+		WordCount:                 atoi(parts[6]),
+	}
+}
+
+// Resolution#Month#Day#Year#CharOffset
+func parseDates(field string) []Date {
+	var dates []Date
+	for _, block := range splitList(field, ";") {
+		parts := strings.Split(block, "#")
+		if len(parts) < 5 {
+			continue
+		}
+		dates = append(dates, Date{
+			Resolution: atoi(parts[0]),
+			Month:      atoi(parts[1]),
+			Day:        atoi(parts[2]),
+			Year:       atoi(parts[3]),
+			CharOffset: atoi(parts[4]),
+		})
+	}
+	return dates
+}
+
+// wc:125,c2.21:4,c10.1:40,v10.1:3.21111111
+func parseGCAM(field string) map[string]float64 {
+	gcam := map[string]float64{}
+	for _, block := range splitList(field, ",") {
+		key, value, found := strings.Cut(block, ":")
+		if found {
+			gcam[key] = atof(value)
+		}
+	}
+	return gcam
+}
+
+// Quotations are delimited by "#", and their fields by "|": Offset|Length|Verb|Quote
+func parseQuotations(field string) []Quotation {
+	var quotations []Quotation
+	for _, block := range splitList(field, "#") {
+		parts := strings.SplitN(block, "|", 4)
+		if len(parts) < 4 {
+			continue
+		}
+		quotations = append(quotations, Quotation{
+			CharOffset: atoi(parts[0]),
+			Length:     atoi(parts[1]),
+			Verb:       parts[2],
+			Quote:      parts[3],
+		})
+	}
+	return quotations
+}
+
+// Amount,Object,CharOffset
+func parseAmounts(field string) []Amount {
+	var amounts []Amount
+	for _, block := range splitList(field, ";") {
+		parts := strings.Split(block, ",")
+		if len(parts) < 3 {
+			continue
+		}
+		amounts = append(amounts, Amount{
+			Amount:     atof(parts[0]),
+			Object:     strings.Join(parts[1:len(parts)-1], ","),
+			CharOffset: atoi(parts[len(parts)-1]),
+		})
+	}
+	return amounts
+}
+
+func Parse(line string) (Record, error) {
+	fields := strings.Split(line, "\t")
+	if len(fields) < NumFields {
+		return Record{}, fmt.Errorf("Expected %d fields in GKG record, got: %d", NumFields, len(fields))
+	}
+	date, err := time.Parse("20060102150405", fields[1])
+	if err != nil {
+		return Record{}, fmt.Errorf("Invalid date %q in GKG record %v", fields[1], fields[0])
+	}
+	return Record{
+		RecordID:                   fields[0],
+		Date:                       date,
+		SourceCollectionIdentifier: atoi(fields[2]),
+		SourceCommonName:           fields[3],
+		DocumentIdentifier:         fields[4],
+		Counts:                     parseCounts(fields[5], false),
+		CountsV21:                  parseCounts(fields[6], true),
+		Themes:                     splitList(fields[7], ";"),
+		EnhancedThemes:             parseMentions(fields[8]),
+		Locations:                  parseLocations(fields[9]),
+		EnhancedLocations:          parseEnhancedLocations(fields[10]),
+		Persons:                    splitList(fields[11], ";"),
+		EnhancedPersons:            parseMentions(fields[12]),
+		Organizations:              splitList(fields[13], ";"),
+		EnhancedOrganizations:      parseMentions(fields[14]),
+		Tone:                       parseTone(fields[15]),
+		EnhancedDates:              parseDates(fields[16]),
+		GCAM:                       parseGCAM(fields[17]),
+		SharingImage:               fields[18],
+		RelatedImages:              splitList(fields[19], ";"),
+		SocialImageEmbeds:          splitList(fields[20], ";"),
+		SocialVideoEmbeds:          splitList(fields[21], ";"),
+		Quotations:                 parseQuotations(fields[22]),
+		AllNames:                   parseMentions(fields[23]),
+		Amounts:                    parseAmounts(fields[24]),
+		TranslationInfo:            fields[25],
+		Extras:                     fields[26],
+	}, nil
+}
+
+// LineError is a line which failed to parse. Reading can carry on past it.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("GKG line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// Records streams a GKG file one record at a time, so that a whole
+// 15 minute file never needs to be in memory. Lines which fail to parse
+// are yielded with a *LineError; any other error means the file couldn't
+// be read any further, and is yielded last. Stop ranging to stop reading.
+func Records(r io.Reader) iter.Seq2[Record, error] {
+	return func(yield func(Record, error) bool) {
+		// Not a bufio.Scanner, because some lines are megabytes long
+		reader := bufio.NewReaderSize(r, 64*1024)
+		for n := 1; ; n++ {
+			line, err := reader.ReadString('\n')
+			if line != "" {
+				record, parse_err := Parse(strings.TrimRight(line, "\r\n"))
+				if parse_err != nil {
+					if !yield(record, &LineError{Line: n, Err: parse_err}) {
+						return
+					}
+				} else if !yield(record, nil) {
+					return
+				}
+			}
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(Record{}, err)
+				return
+			}
+		}
+	}
+}
+
+var pageTitle = regexp.MustCompile("<PAGE_TITLE>(.*?)</PAGE_TITLE>")
+
+// Title returns the <PAGE_TITLE> from the extras xml, if any
+func (r Record) Title() string {
+	matches := pageTitle.FindStringSubmatch(r.Extras)
+	if len(matches) < 2 {
+		return ""
+	}
+	return matches[1]
+}
+
+// Count returns the largest count of the given type, e.g. "KILL"
+func (r Record) Count(count_type string) int {
+	max_count := 0
+	for _, count := range r.Counts {
+		if count.Type == count_type && count.Number > max_count {
+			max_count = count.Number
+		}
+	}
+	return max_count
+}
+
+func (r Record) IsTranslated() bool {
+	return r.TranslationInfo != "" || strings.Contains(r.RecordID, "-T")
+}
+
+// SourceLanguage returns the ISO 639-2 code of the language the article was
+// written in, e.g. "fra", from translation info like
+// "srclc:fra;eng:GT-FRA 1.0.1". Untranslated articles are "eng".
+func (r Record) SourceLanguage() string {
+	for _, part := range strings.Split(r.TranslationInfo, ";") {
+		code, found := strings.CutPrefix(part, "srclc:")
+		if found && code != "" {
+			return code
+		}
+	}
+	return "eng"
+}
@@ -0,0 +1,172 @@
+package gkg
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+/*
+Trigger rules decide which GKG records are worth fetching. A rule is a tree
+of conditions, each of which is exactly one of:
+
+	all: [conditions]      every one of them matches
+	any: [conditions]      at least one of them matches
+	not: condition         it doesn't match
+	theme: TAX_DISEASE_*   the record has this theme; a trailing * matches any suffix
+	count: {type: KILL, min: 100}
+	                       the largest count of this type is at least min
+	tone: {min: -100, max: -5}
+	                       the document's tone is within these bounds
+	countries: [UP, RS]    one of its locations is in one of these (FIPS) countries
+
+See config/gdelt.yaml for examples.
+*/
+
+type CountCondition struct {
+	Type string `yaml:"type"`
+	Min  int    `yaml:"min"`
+}
+
+type ToneCondition struct {
+	Min *float64 `yaml:"min"`
+	Max *float64 `yaml:"max"`
+}
+
+type Condition struct {
+	All       []Condition     `yaml:"all"`
+	Any       []Condition     `yaml:"any"`
+	Not       *Condition      `yaml:"not"`
+	Theme     string          `yaml:"theme"`
+	Count     *CountCondition `yaml:"count"`
+	Tone      *ToneCondition  `yaml:"tone"`
+	Countries []string        `yaml:"countries"`
+}
+
+type Trigger struct {
+	Name      string `yaml:"name"`
+	Condition `yaml:",inline"`
+}
+
+func (c Condition) numKinds() int {
+	n := 0
+	for _, set := range []bool{c.All != nil, c.Any != nil, c.Not != nil, c.Theme != "", c.Count != nil, c.Tone != nil, c.Countries != nil} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+func (c Condition) Validate() error {
+	if c.numKinds() != 1 {
+		return errors.New("each condition needs exactly one of all, any, not, theme, count, tone or countries")
+	}
+	switch {
+	case c.All != nil || c.Any != nil:
+		if len(c.All)+len(c.Any) == 0 {
+			return errors.New("all and any need at least one condition")
+		}
+		for _, sub := range slices.Concat(c.All, c.Any) {
+			if err := sub.Validate(); err != nil {
+				return err
+			}
+		}
+	case c.Not != nil:
+		return c.Not.Validate()
+	case c.Count != nil:
+		if c.Count.Type == "" {
+			return errors.New("count needs a type, e.g. KILL")
+		}
+	case c.Tone != nil:
+		if c.Tone.Min == nil && c.Tone.Max == nil {
+			return errors.New("tone needs a min or a max")
+		}
+	case c.Countries != nil:
+		if len(c.Countries) == 0 {
+			return errors.New("countries needs at least one country")
+		}
+	}
+	return nil
+}
+
+func (t Trigger) Validate() error {
+	if t.Name == "" {
+		return errors.New("GKG trigger needs a name")
+	}
+	if err := t.Condition.Validate(); err != nil {
+		return fmt.Errorf("GKG trigger %q: %v", t.Name, err)
+	}
+	return nil
+}
+
+func (r Record) HasTheme(pattern string) bool {
+	prefix, is_prefix := strings.CutSuffix(pattern, "*")
+	for _, theme := range r.Themes {
+		if theme == pattern || (is_prefix && strings.HasPrefix(theme, prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Record) InCountries(countries []string) bool {
+	for _, location := range r.EnhancedLocations {
+		if slices.Contains(countries, location.CountryCode) {
+			return true
+		}
+	}
+	for _, location := range r.Locations {
+		if slices.Contains(countries, location.CountryCode) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Condition) Matches(r Record) bool {
+	switch {
+	case c.All != nil:
+		for _, sub := range c.All {
+			if !sub.Matches(r) {
+				return false
+			}
+		}
+		return true
+	case c.Any != nil:
+		for _, sub := range c.Any {
+			if sub.Matches(r) {
+				return true
+			}
+		}
+		return false
+	case c.Not != nil:
+		return !c.Not.Matches(r)
+	case c.Theme != "":
+		return r.HasTheme(c.Theme)
+	case c.Count != nil:
+		return r.Count(c.Count.Type) >= c.Count.Min
+	case c.Tone != nil:
+		if c.Tone.Min != nil && r.Tone.Tone < *c.Tone.Min {
+			return false
+		}
+		if c.Tone.Max != nil && r.Tone.Tone > *c.Tone.Max {
+			return false
+		}
+		return true
+	case c.Countries != nil:
+		return r.InCountries(c.Countries)
+	}
+	return false
+}
+
+// FirstMatch returns the first trigger the record matches
+func FirstMatch(triggers []Trigger, r Record) (Trigger, bool) {
+	for _, trigger := range triggers {
+		if trigger.Matches(r) {
+			return trigger, true
+		}
+	}
+	return Trigger{}, false
+}
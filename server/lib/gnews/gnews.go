@@ -0,0 +1,165 @@
+package gnews
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+/*
+Google News search results as RSS, e.g.
+
+	https://news.google.com/rss/search?q=blockade+when:1d&hl=en-US&gl=US&ceid=US:en
+
+Item links point to news.google.com/rss/articles/<id> rather than to the
+publisher. Older ids are the base64 of a protobuf holding the publisher url;
+newer ones, starting with AU_yqL once decoded, have to be exchanged for it
+through the same endpoint news.google.com's own redirect page calls.
+*/
+
+const (
+	searchURL  = "https://news.google.com/rss/search"
+	articleURL = "https://news.google.com/rss/articles/"
+	decodeURL  = "https://news.google.com/_/DotsSplashUi/data/batchexecute"
+)
+
+type Params struct {
+	Language string // e.g. en-US
+	Region   string // e.g. US
+	When     string // e.g. 1h, 1d, 7d; empty for any time
+}
+
+// SearchURL builds the RSS url for a query
+func SearchURL(query string, params Params) string {
+	q := query
+	if params.When != "" {
+		q += " when:" + params.When
+	}
+	language, _, _ := strings.Cut(params.Language, "-")
+	values := url.Values{}
+	values.Set("q", q)
+	values.Set("hl", params.Language)
+	values.Set("gl", params.Region)
+	values.Set("ceid", params.Region+":"+language)
+	return searchURL + "?" + values.Encode()
+}
+
+func articleID(link string) (string, bool) {
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Host != "news.google.com" {
+		return "", false
+	}
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(parts) < 2 || parts[len(parts)-2] != "articles" {
+		return "", false
+	}
+	return parts[len(parts)-1], true
+}
+
+// decodeOldID reads the publisher url straight out of the id, if it's there
+func decodeOldID(id string) (string, bool) {
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(id, "="))
+	if err != nil {
+		return "", false
+	}
+	start := bytes.Index(decoded, []byte("http"))
+	if start == -1 {
+		return "", false
+	}
+	end := start
+	for end < len(decoded) && decoded[end] > 0x20 && decoded[end] < 0x7f {
+		end++
+	}
+	return string(decoded[start:end]), true
+}
+
+// decodeNewID asks Google for the url, with the signature and timestamp
+// from the article's redirect page
+func decodeNewID(id string) (string, error) {
+	resp, err := http.Get(articleURL + id)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching google news article page: http status %v", resp.StatusCode)
+	}
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	node := doc.Find("[data-n-a-sg]").First()
+	signature, has_signature := node.Attr("data-n-a-sg")
+	timestamp, has_timestamp := node.Attr("data-n-a-ts")
+	if !has_signature || !has_timestamp {
+		return "", errors.New("google news article page has no decoding parameters")
+	}
+
+	request := fmt.Sprintf(`["garturlreq",[["X","X",["X","X"],null,null,1,1,"US:en",null,1,null,null,null,null,null,0,1],"X","X",1,[1,1,1],1,1,null,0,0,null,0],%q,%s,%q]`, id, timestamp, signature)
+	payload, _ := json.Marshal([][][]any{{{"Fbv4je", request, nil, "generic"}}})
+	form := url.Values{"f.req": {string(payload)}}
+	resp, err = http.Post(decodeURL, "application/x-www-form-urlencoded;charset=UTF-8", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("decoding google news url: http status %v", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return parseDecodeResponse(body)
+}
+
+// The response is )]}' and then, after a blank line, json like
+// [["wrb.fr","Fbv4je","[\"garturlres\",\"https://...\",1]",...],...]
+func parseDecodeResponse(body []byte) (string, error) {
+	_, rest, found := bytes.Cut(body, []byte("\n\n"))
+	if !found {
+		return "", errors.New("unexpected google news decoding response")
+	}
+	var envelope [][]any
+	if err := json.NewDecoder(bytes.NewReader(rest)).Decode(&envelope); err != nil {
+		return "", fmt.Errorf("parsing google news decoding response: %w", err)
+	}
+	for _, entry := range envelope {
+		if len(entry) < 3 {
+			continue
+		}
+		inner, ok := entry[2].(string)
+		if !ok {
+			continue
+		}
+		var result []any
+		if err := json.Unmarshal([]byte(inner), &result); err != nil || len(result) < 2 {
+			continue
+		}
+		if link, ok := result[1].(string); ok && strings.HasPrefix(link, "http") {
+			return link, nil
+		}
+	}
+	return "", errors.New("no url in google news decoding response")
+}
+
+// PublisherURL turns a news.google.com article link into the publisher's
+// url. Other links are returned as they are.
+func PublisherURL(link string) (string, error) {
+	id, ok := articleID(link)
+	if !ok {
+		return link, nil
+	}
+	if publisher_url, ok := decodeOldID(id); ok {
+		return publisher_url, nil
+	}
+	return decodeNewID(id)
+}
@@ -0,0 +1,113 @@
+package hosts
+
+import (
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Rules for hosts which we can't, or shouldn't, read directly. See
+config/hosts.yaml. Each host has a list of actions, tried in order until one
+of them yields a readable article:
+
+- direct: fetch the page itself
+- mirror: fetch the same path from an alternative frontend (e.g. neuters.de)
+- archive: fetch the closest Wayback Machine snapshot
+- title_only: give up on the body, and score the item on its title and lede
+- skip: drop items from this host altogether
+*/
+
+const (
+	Direct    = "direct"
+	Mirror    = "mirror"
+	Archive   = "archive"
+	TitleOnly = "title_only"
+	Skip      = "skip"
+)
+
+type Action struct {
+	Type string `yaml:"type"`
+	Host string `yaml:"host,omitempty"` // for mirrors
+}
+
+type Rule struct {
+	Host    string   `yaml:"host"`
+	Actions []Action `yaml:"actions"`
+}
+
+type Rules struct {
+	Hosts []Rule `yaml:"hosts"`
+}
+
+var RulesPath = "config/hosts.yaml"
+
+var (
+	rules      Rules
+	rules_once sync.Once
+)
+
+func Load(path string) (Rules, error) {
+	var r Rules
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("Error reading host rules: %v", err)
+		return r, err
+	}
+	err = yaml.Unmarshal(data, &r)
+	if err != nil {
+		log.Printf("Error parsing host rules in %v: %v", path, err)
+		return r, err
+	}
+	return r, nil
+}
+
+func getRules() Rules {
+	rules_once.Do(func() {
+		r, err := Load(RulesPath)
+		if err != nil {
+			log.Printf("Continuing without host rules; all hosts will be fetched directly")
+			return
+		}
+		rules = r
+	})
+	return rules
+}
+
+// A rule for "washingtonpost.com" also applies to "www.washingtonpost.com"
+func matches(rule_host string, host string) bool {
+	return host == rule_host || strings.HasSuffix(host, "."+rule_host)
+}
+
+func ActionsFor(link string) []Action {
+	parsed_url, err := url.Parse(link)
+	if err != nil {
+		log.Printf("Error parsing link: %v", err)
+		return []Action{{Type: Direct}}
+	}
+	for _, rule := range getRules().Hosts {
+		if matches(rule.Host, parsed_url.Hostname()) && len(rule.Actions) > 0 {
+			return rule.Actions
+		}
+	}
+	return []Action{{Type: Direct}}
+}
+
+func IsSkipped(link string) bool {
+	actions := ActionsFor(link)
+	return len(actions) > 0 && actions[0].Type == Skip
+}
+
+func MirrorURL(link string, mirror_host string) (string, error) {
+	parsed_url, err := url.Parse(link)
+	if err != nil {
+		log.Printf("Error parsing url to replace with mirror: %v", err)
+		return "", err
+	}
+	parsed_url.Host = mirror_host
+	return parsed_url.String(), nil
+}
@@ -3,6 +3,8 @@ package llm
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"strings"
 
@@ -59,7 +61,7 @@ func fetchOpenAIAnswerJSON(req OpenAIRequest) (string, error) {
 					Content: req.prompt,
 				},
 			},
-			ResponseFormat: &openai.ChatCompletionResponseFormat{"json_object"},
+			ResponseFormat: &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
 		},
 	)
 
@@ -197,6 +199,152 @@ For a longer example, given the following article\n\n<INPUT>`
 	return &existential_importance_box, nil
 }
 
+func CheckExistentialImportanceBio(text string, token string) (*ExistentialImportanceBox, error) {
+	prompt := `The existential importance json API endpoint returns a {existential_importance_reasoning, existential_importance_bool, high_importance_bool, error} object.
+
+The existential_importance_reasoning field contains, as a string, a determination of whether the input describes a biological event of global importance. existential_importance_bool contains the result of that determination as a true/false boolean. high_importance_bool contains, as a true/false boolean, whether the event is highly important, even if it is not of "existential" importance.
+
+Items are of existential importance if they involve:
+
+- A novel pathogen, or a new strain or variant of a known one, particularly with changes in transmissibility, severity, immune escape or drug resistance
+- Sustained human-to-human transmission of a pathogen that normally spreads from animals, like H5N1 or other avian or swine influenzas, or MERS
+- A pathogen spreading to a new species, e.g. avian influenza in dairy cattle, or to a new country or continent
+- Clusters of unexplained illness or deaths, like a "mystery pneumonia"
+- Outbreaks growing quickly, or with more than a hundred deaths, or in large cities or transport hubs
+- Lab accidents, gain of function research, or the use or development of biological weapons
+- Failures in the response, e.g. vaccine or treatment shortages, or a country hiding or under-reporting an outbreak
+
+For example:
+
+- First human case of H5N1 with no animal exposure: existentially important, as it may mean human-to-human transmission.
+- A new mpox clade spreads outside of Africa: existentially important.
+- WHO declares a Public Health Emergency of International Concern: existentially important.
+- Cholera outbreak in a war zone, with hundreds of deaths: existentially important, although cholera is well known.
+- A routine update on seasonal flu, dengue or measles numbers, in line with previous seasons: of high importance at most.
+- Single imported case of a known disease, quickly contained: not existentially important.
+- Opinion pieces, reviews and retrospectives, e.g. "lessons from covid", are not existentially important.
+
+For now, the API leans towards having a light trigger, because false positives are less costly than false negatives.
+
+For a longer example, given the following item\n\n<INPUT>`
+	prompt += text + "\n\n</INPUT>\n\nThe output is as follows: (As a reminder, the existential importance json API endpoint returns a {existential_importance_reasoning, existential_importance_bool, high_importance_bool, error} object, opinion pieces, or editorials are not categorizes as existentially important.)\n"
+	answer_json, err := fetchOpenAIAnswerJSON(OpenAIRequest{prompt: prompt, model: GPT4_o_mini, token: token})
+	if err != nil {
+		return nil, err
+	}
+
+	var existential_importance_box ExistentialImportanceBox
+	err = json.Unmarshal([]byte(answer_json), &existential_importance_box)
+	if err != nil {
+		log.Printf("Error unmarshalling json: %v", err)
+		return nil, err
+	}
+	if existential_importance_box.Error != nil && *existential_importance_box.Error != "" {
+		log.Printf("OpenAI json error field is not empty: %v", *existential_importance_box.Error)
+		log.Printf("OpenAI answer: %v", answer_json)
+		return nil, errors.New(*existential_importance_box.Error)
+	}
+	return &existential_importance_box, nil
+}
+
+func CheckExistentialImportanceConflict(text string, token string) (*ExistentialImportanceBox, error) {
+	prompt := `The existential importance json API endpoint returns a {existential_importance_reasoning, existential_importance_bool, high_importance_bool, error} object.
+
+The existential_importance_reasoning field contains, as a string, a determination of whether the input describes a conflict or international development of global importance. existential_importance_bool contains the result of that determination as a true/false boolean. high_importance_bool contains, as a true/false boolean, whether the event is highly important, even if it is not of "existential" importance.
+
+Items are of existential importance if they involve:
+
+- Conflict, or a breakdown in relations, between nuclear powers, or between a nuclear power and an ally of another
+- The start of a war, or a new country, alliance or great power joining one
+- Escalations in an ongoing war: new kinds of weapons, strikes on a new country, on nuclear plants or on civilian infrastructure at scale, or talk of using nuclear weapons
+- Terrorist groups or militias displaying new capabilities
+- More than a hundred deaths in a single attack or battle
+- Coups, or the collapse of a government, in a nuclear power or a major power
+- The end or breakdown of an arms control treaty, a ceasefire or a peace process between major powers
+
+For example:
+
+- Macron suggests sending NATO troops to Ukraine: existentially important, as a NATO v. Russia conflict could spiral into a global war.
+- Houthis cut undersea internet cables: existentially important, as it is a terrorist group displaying new capabilities.
+- India and Pakistan exchange strikes after a terrorist attack: existentially important.
+- Later, small-fry developments of an ongoing war, e.g. a village changing hands in Ukraine or Gaza: of high importance at most, unless they involve more than a thousand deaths, escalation or nuclear weapons.
+- A state visit or trade agreement between countries not in conflict: not existentially important.
+- Opinion pieces, reviews and retrospectives are not existentially important.
+
+For now, the API leans towards having a light trigger, because false positives are less costly than false negatives.
+
+For a longer example, given the following item\n\n<INPUT>`
+	prompt += text + "\n\n</INPUT>\n\nThe output is as follows: (As a reminder, the existential importance json API endpoint returns a {existential_importance_reasoning, existential_importance_bool, high_importance_bool, error} object, opinion pieces, or editorials are not categorizes as existentially important.)\n"
+	answer_json, err := fetchOpenAIAnswerJSON(OpenAIRequest{prompt: prompt, model: GPT4_o_mini, token: token})
+	if err != nil {
+		return nil, err
+	}
+
+	var existential_importance_box ExistentialImportanceBox
+	err = json.Unmarshal([]byte(answer_json), &existential_importance_box)
+	if err != nil {
+		log.Printf("Error unmarshalling json: %v", err)
+		return nil, err
+	}
+	if existential_importance_box.Error != nil && *existential_importance_box.Error != "" {
+		log.Printf("OpenAI json error field is not empty: %v", *existential_importance_box.Error)
+		log.Printf("OpenAI answer: %v", answer_json)
+		return nil, errors.New(*existential_importance_box.Error)
+	}
+	return &existential_importance_box, nil
+}
+
+// Importance prompts which sources can pick by name, e.g. in config/feeds.yaml
+var ImportanceChecks = map[string]func(text string, token string) (*ExistentialImportanceBox, error){
+	"default":  CheckExistentialImportance,
+	"china":    CheckExistentialImportanceChina,
+	"bio":      CheckExistentialImportanceBio,
+	"conflict": CheckExistentialImportanceConflict,
+}
+
+// What an outbreak report is about. Counts are -1 if the report doesn't
+// give them.
+type OutbreakBox struct {
+	Pathogen string  `json:"pathogen"`
+	Location string  `json:"location"`
+	Cases    int     `json:"cases"`
+	Deaths   int     `json:"deaths"`
+	Error    *string `json:"error"`
+}
+
+// Returned by ExtractOutbreak for a report which isn't about a disease
+// outbreak, as opposed to a failed request
+var ErrNotAnOutbreak = errors.New("Not about a disease outbreak")
+
+func ExtractOutbreak(text string, token string) (*OutbreakBox, error) {
+	prompt := `The outbreak json API endpoint returns a {pathogen, location, cases, deaths, error} object, describing the disease outbreak report it is given.
+
+- pathogen is the disease or pathogen, as specific as the report allows, using its common English name, e.g. "avian influenza A(H5N1)", "Marburg virus disease", "cholera"
+- location is the country where the outbreak is, e.g. "Cambodia", or "multi-country" if it spans several
+- cases is the total number of cases reported so far, suspected and confirmed, as an integer, or -1 if the report doesn't say
+- deaths is the total number of deaths reported so far, as an integer, or -1 if the report doesn't say
+- error is null, unless the report isn't about a disease outbreak
+
+Given the following report\n\n<INPUT>`
+	prompt += text + "\n\n</INPUT>\n\nThe output is as follows:\n"
+	answer_json, err := fetchOpenAIAnswerJSON(OpenAIRequest{prompt: prompt, model: GPT4_o_mini, token: token})
+	if err != nil {
+		return nil, err
+	}
+
+	var outbreak_box OutbreakBox
+	err = json.Unmarshal([]byte(answer_json), &outbreak_box)
+	if err != nil {
+		log.Printf("Error unmarshalling json: %v", err)
+		return nil, err
+	}
+	if outbreak_box.Error != nil && *outbreak_box.Error != "" {
+		log.Printf("OpenAI answer: %v", answer_json)
+		return nil, fmt.Errorf("%w: %s", ErrNotAnOutbreak, *outbreak_box.Error)
+	}
+	return &outbreak_box, nil
+}
+
 func TranslateString(text string, token string) (string, error) {
 	prompt := "Translate this text into English: " + text + "\n"
 	translation, err := fetchOpenAIAnswer(OpenAIRequest{prompt: prompt, model: GPT4_turbo, token: token})
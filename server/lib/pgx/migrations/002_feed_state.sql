@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS feed_state (
+    url TEXT PRIMARY KEY,
+    etag TEXT NOT NULL DEFAULT '',
+    last_modified TEXT NOT NULL DEFAULT '',
+    last_entry_id TEXT NOT NULL DEFAULT '',
+    -- Entries still in the feed which have been processed or given up on,
+    -- and failed attempts at the rest, see lib/feedstate
+    done_entry_ids TEXT[] NOT NULL DEFAULT '{}',
+    entry_attempts JSONB NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,4 @@
+-- For sources whose date was read off the article itself: where it was
+-- found (see lib/pubdate), and the date we had before that.
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS original_date TIMESTAMP;
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS date_method TEXT;
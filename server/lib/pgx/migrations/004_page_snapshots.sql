@@ -0,0 +1,25 @@
+-- Content-addressed, gzipped page contents, shared between snapshots
+CREATE TABLE IF NOT EXISTS page_blobs (
+    hash TEXT PRIMARY KEY,
+    data BYTEA NOT NULL,
+    size INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One row per fetch of a page: the raw html, and the text we extracted from it
+CREATE TABLE IF NOT EXISTS page_snapshots (
+    id SERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    requested_url TEXT NOT NULL,
+    fetched_at TIMESTAMP NOT NULL,
+    html_hash TEXT REFERENCES page_blobs(hash),
+    text_hash TEXT REFERENCES page_blobs(hash)
+);
+CREATE INDEX IF NOT EXISTS page_snapshots_url_fetched_at ON page_snapshots (url, fetched_at);
+
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS snapshot_id INTEGER REFERENCES page_snapshots(id) ON DELETE SET NULL;
+
+-- Which of llm.ImportanceChecks a source was scored with, so that it's
+-- re-scored with the same one. Rows from before are NULL, which is taken
+-- to mean the default prompt.
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS importance_prompt TEXT;
@@ -0,0 +1,3 @@
+-- Free-form labels from the source's config, e.g. the feed's tags in
+-- config/feeds.yaml, so the client can group or filter on them.
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS tags TEXT[];
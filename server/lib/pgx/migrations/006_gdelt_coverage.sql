@@ -0,0 +1,17 @@
+-- Mention and source counts per GDELT event and 15 minute window, from the
+-- mentions files. See lib/gdelt/coverage.go.
+CREATE TABLE IF NOT EXISTS gdelt_event_coverage (
+    global_event_id BIGINT NOT NULL,
+    window_start TIMESTAMP NOT NULL,
+    num_mentions INTEGER NOT NULL,
+    num_sources INTEGER NOT NULL,
+    -- Which sources mentioned the event, so that they can be counted once
+    -- across windows rather than once per window
+    sources TEXT[] NOT NULL DEFAULT '{}',
+    PRIMARY KEY (global_event_id, window_start)
+);
+
+CREATE INDEX IF NOT EXISTS gdelt_event_coverage_window_start_idx ON gdelt_event_coverage (window_start);
+
+-- How many sources had reported the story when we saved it, for the client
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS num_reporting_sources INTEGER;
@@ -0,0 +1,7 @@
+-- The last GDELT 15 minute interval the gdelt source finished, so that it
+-- can catch up on the ones it missed. A single row.
+CREATE TABLE IF NOT EXISTS gdelt_progress (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    last_processed TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
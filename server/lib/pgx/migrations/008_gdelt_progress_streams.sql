@@ -0,0 +1,25 @@
+-- Keep GDELT progress per stream: "english", from lastupdate.txt, and
+-- "translingual", from lastupdate-translation.txt; and per file: "gkg" for
+-- the GKG file and "events" for the events file, so that a failure reading
+-- one doesn't make the other, already scored, be processed again. The
+-- existing row is where both of the English stream's files had got to.
+ALTER TABLE gdelt_progress ADD COLUMN IF NOT EXISTS stream TEXT NOT NULL DEFAULT 'english';
+ALTER TABLE gdelt_progress ADD COLUMN IF NOT EXISTS part TEXT NOT NULL DEFAULT 'gkg';
+
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'gdelt_progress' AND column_name = 'id'
+    ) THEN
+        ALTER TABLE gdelt_progress DROP COLUMN id;
+    END IF;
+END $$;
+
+ALTER TABLE gdelt_progress DROP CONSTRAINT IF EXISTS gdelt_progress_pkey;
+ALTER TABLE gdelt_progress ADD PRIMARY KEY (stream, part);
+
+INSERT INTO gdelt_progress (stream, part, last_processed, updated_at)
+SELECT stream, 'events', last_processed, updated_at FROM gdelt_progress
+WHERE stream = 'english' AND part = 'gkg'
+ON CONFLICT (stream, part) DO NOTHING;
@@ -0,0 +1,5 @@
+-- When each polled feed was last fetched successfully, and how many items
+-- it has had, for make galerts-stats and similar
+ALTER TABLE feed_state ADD COLUMN IF NOT EXISTS last_success_at TIMESTAMP;
+ALTER TABLE feed_state ADD COLUMN IF NOT EXISTS num_items INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE feed_state ADD COLUMN IF NOT EXISTS num_new_items BIGINT NOT NULL DEFAULT 0;
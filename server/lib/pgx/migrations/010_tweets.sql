@@ -0,0 +1,29 @@
+-- Tweets saved by sources/tweets and read by client/tweets. Until now the
+-- client read dwarkesh_tweets, which was filled by hand; its rows are
+-- copied over if it exists.
+CREATE TABLE IF NOT EXISTS tweets (
+    tweetid TEXT PRIMARY KEY,
+    text TEXT NOT NULL,
+    author TEXT NOT NULL,
+    url TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    processed BOOLEAN NOT NULL DEFAULT FALSE,
+    -- NULL when the importance check is off in config/tweets.yaml
+    importance_bool BOOLEAN,
+    importance_reasoning TEXT,
+    -- The account list or query it came from, and the config's tags
+    tags TEXT[],
+    inserted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS tweets_unprocessed_idx ON tweets (created_at) WHERE processed = FALSE;
+
+DO $$
+BEGIN
+    IF to_regclass('dwarkesh_tweets') IS NOT NULL THEN
+        INSERT INTO tweets (tweetid, text, author, url, created_at, processed, tags)
+        SELECT tweetid::TEXT, text, author, url, created_at, processed, ARRAY['dwarkesh']
+        FROM dwarkesh_tweets
+        ON CONFLICT (tweetid) DO NOTHING;
+    END IF;
+END $$;
@@ -0,0 +1,28 @@
+-- Pathogen and location pairs that sources/outbreaks has seen reports
+-- about, so that it can flag the first report of a disease in a place.
+-- Both are lowercased.
+CREATE TABLE IF NOT EXISTS outbreak_pairs (
+    pathogen TEXT NOT NULL,
+    location TEXT NOT NULL,
+    first_link TEXT NOT NULL,
+    first_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    num_reports INTEGER NOT NULL DEFAULT 1,
+    PRIMARY KEY (pathogen, location)
+);
+
+-- What sources/outbreaks read off each report it saved, so that case and
+-- death counts can be queried rather than fished out of summaries. Counts
+-- are NULL where the report didn't give them.
+CREATE TABLE IF NOT EXISTS outbreak_reports (
+    link TEXT PRIMARY KEY,
+    pathogen TEXT NOT NULL,
+    location TEXT NOT NULL,
+    num_cases INTEGER,
+    num_deaths INTEGER,
+    -- Whether it was the first report we saved about this pathogen and location
+    is_new_pair BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS outbreak_reports_pair_idx ON outbreak_reports (pathogen, location);
@@ -0,0 +1,16 @@
+-- Ongoing space weather events found by sources/spaceweather, one per NOAA
+-- scale (G, R or S) and episode. Each has one row in sources, found by
+-- link, which is updated as the event evolves rather than duplicated.
+CREATE TABLE IF NOT EXISTS space_weather_events (
+    id SERIAL PRIMARY KEY,
+    scale TEXT NOT NULL,
+    link TEXT NOT NULL UNIQUE,
+    started_at TIMESTAMP NOT NULL,
+    last_seen_at TIMESTAMP NOT NULL,
+    -- 1 to 5 on the scale, or 0 for a watch or warning
+    peak_level INTEGER NOT NULL DEFAULT 0,
+    -- One observation per line, oldest first
+    timeline TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS space_weather_events_scale_idx ON space_weather_events (scale, last_seen_at);
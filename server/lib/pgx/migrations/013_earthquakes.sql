@@ -0,0 +1,22 @@
+-- Earthquakes saved by sources/earthquakes, with the fields USGS revises.
+-- Each has one row in sources, found by link, which is updated when USGS
+-- revises the event rather than duplicated.
+CREATE TABLE IF NOT EXISTS earthquakes (
+    id SERIAL PRIMARY KEY,
+    -- Every id USGS has given the event; the preferred one can change
+    usgs_ids TEXT[] NOT NULL,
+    link TEXT NOT NULL UNIQUE,
+    magnitude DOUBLE PRECISION NOT NULL,
+    place TEXT NOT NULL,
+    latitude DOUBLE PRECISION NOT NULL,
+    longitude DOUBLE PRECISION NOT NULL,
+    depth_km DOUBLE PRECISION NOT NULL,
+    alert TEXT NOT NULL DEFAULT '',
+    tsunami BOOLEAN NOT NULL DEFAULT FALSE,
+    status TEXT NOT NULL DEFAULT '',
+    occurred_at TIMESTAMP NOT NULL,
+    usgs_updated_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS earthquakes_usgs_ids_idx ON earthquakes USING GIN (usgs_ids);
@@ -0,0 +1,170 @@
+package pipeline
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/filters"
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"git.nunosempere.com/NunoSempere/news/lib/pubdate"
+	"git.nunosempere.com/NunoSempere/news/lib/readability"
+	"git.nunosempere.com/NunoSempere/news/lib/snapshots"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+/*
+The steps which the config-driven sources (feeds, gnews, scrapers, social,
+telegram, outbreaks) put a link through: dedupe, freshness, host filter,
+extraction, snapshot, date, summary and importance check, and then saving
+to the sources table. Sources say where an item came from and with which
+settings, and this does the rest.
+
+Functions return an error when a step failed in a way that's worth
+retrying, e.g. an OpenAI error, and ok = false, nil when the item was
+filtered out.
+*/
+
+// A link to consider, as found in a feed, a search, a list page or a post
+type Item struct {
+	Title      string // if empty, the page's own title is used
+	Link       string
+	Date       time.Time // if zero, it's read off the page
+	DateMethod string    // where Date came from, e.g. "feed"
+	Text       string    // if set, it's summarized instead, and the page isn't fetched
+	FreshDays  int
+	Prompt     string // which of llm.ImportanceChecks to score it with
+	Tags       []string
+}
+
+func IsFresh(date time.Time, fresh_days int) bool {
+	now := time.Now()
+	days_before := now.AddDate(0, 0, -fresh_days)
+	days_after := now.AddDate(0, 0, 15)
+	return date.After(days_before) && date.Before(days_after)
+}
+
+// Expand checks that the item is new, fresh and from a host we read, and
+// fetches, snapshots, dates and summarizes it
+func Expand(item Item, openai_key string, database_url string) (types.ExpandedSource, bool, error) {
+	title := item.Title
+	if title == "" {
+		title = item.Link
+	}
+	source := types.Source{Title: title, Link: item.Link}
+	expanded_source := types.ExpandedSource{
+		Title:      title,
+		Link:       item.Link,
+		Date:       time.Now().Format(time.RFC3339),
+		DateMethod: "fetch time",
+		Tags:       item.Tags,
+	}
+
+	is_dupe := filters.IsDupe(source, database_url)
+	if is_dupe {
+		return expanded_source, false, nil
+	}
+
+	// Items without dates are checked once we've read a date off the page
+	if !item.Date.IsZero() {
+		expanded_source.Date = item.Date.Format(time.RFC3339)
+		expanded_source.DateMethod = item.DateMethod
+		if !IsFresh(item.Date, item.FreshDays) {
+			log.Printf("Item is not fresh")
+			return expanded_source, false, nil
+		}
+	}
+
+	is_good_host := filters.IsGoodHost(source)
+	if !is_good_host {
+		return expanded_source, false, nil
+	}
+
+	expanded_source.Title = filters.CleanTitle(expanded_source.Title)
+
+	text := item.Text
+	is_lede_only := false
+	if text == "" {
+		article, err := readability.GetArticle(item.Link)
+		if err != nil {
+			log.Printf("Readability extraction failed for %s: %v", item.Link, err)
+			return expanded_source, false, nil
+		}
+		if item.Title == "" && article.Title != "" {
+			expanded_source.Title = filters.CleanTitle(article.Title)
+			if filters.IsDupe(types.Source{Title: expanded_source.Title, Link: item.Link}, database_url) {
+				return expanded_source, false, nil
+			}
+		}
+
+		// Keep the page we scored, so that it can be re-scored or shown later
+		snapshot_id, err := snapshots.Save(item.Link, article.Html, article.Content, database_url)
+		if err == nil {
+			expanded_source.SnapshotID = snapshot_id
+		}
+
+		if item.Date.IsZero() {
+			if published, ok := pubdate.Resolve(article.PublishedTime, article.PublishedMethod, item.Link); ok {
+				expanded_source.OriginalDate = expanded_source.Date
+				expanded_source.Date = published.Date.Format(time.RFC3339)
+				expanded_source.DateMethod = published.Method
+				if !IsFresh(published.Date, item.FreshDays) {
+					log.Printf("Article is not fresh")
+					return expanded_source, false, nil
+				}
+			}
+		}
+		text = article.Content
+		is_lede_only = article.TitleOnly
+	}
+
+	if is_lede_only {
+		// Paywalled: score on the title and lede rather than dropping it
+		expanded_source.Summary = "[Paywalled, lede only] " + text
+	} else {
+		summary, err := llm.Summarize(text, openai_key)
+		if err != nil {
+			log.Printf("Summarization failed for %s: %v", item.Link, err)
+			return expanded_source, false, err
+		}
+		expanded_source.Summary = summary
+	}
+	log.Printf("Summary: %s", expanded_source.Summary)
+
+	return expanded_source, true, nil
+}
+
+// CheckImportance scores the source's title and summary with one of
+// llm.ImportanceChecks
+func CheckImportance(expanded_source *types.ExpandedSource, prompt string, openai_key string) error {
+	check_importance := llm.ImportanceChecks[prompt]
+	existential_importance_snippet := "# " + expanded_source.Title + "\n\n" + expanded_source.Summary
+	existential_importance_box, err := check_importance(existential_importance_snippet, openai_key)
+	if err == nil && existential_importance_box == nil {
+		err = errors.New("No importance check result")
+	}
+	if err != nil {
+		log.Printf("Importance check failed for %s: %v", expanded_source.Link, err)
+		return err
+	}
+	expanded_source.ImportanceBool = existential_importance_box.ExistentialImportanceBool
+	expanded_source.ImportanceReasoning = existential_importance_box.ExistentialImportanceReasoning
+	expanded_source.ImportancePrompt = prompt
+	log.Printf("Importance bool: %t", expanded_source.ImportanceBool)
+	log.Printf("Reasoning: %s", expanded_source.ImportanceReasoning)
+	return nil
+}
+
+// FilterAndExpand expands the item and scores it. ok is whether it's
+// important enough to save.
+func FilterAndExpand(item Item, openai_key string, database_url string) (types.ExpandedSource, bool, error) {
+	expanded_source, ok, err := Expand(item, openai_key, database_url)
+	if !ok || err != nil {
+		return expanded_source, false, err
+	}
+	err = CheckImportance(&expanded_source, item.Prompt, openai_key)
+	if err != nil {
+		return expanded_source, false, err
+	}
+	return expanded_source, expanded_source.ImportanceBool, nil
+}
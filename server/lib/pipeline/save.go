@@ -0,0 +1,118 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrAlreadySaved = errors.New("Source already saved")
+
+// Save inserts the source into the sources table. If its link is already
+// there, nothing changes and ErrAlreadySaved is returned.
+func Save(source types.ExpandedSource, database_url string) error {
+	return InTransaction(database_url, func(tx pgx.Tx) error {
+		return InsertSource(tx, source)
+	})
+}
+
+// InTransaction runs fn in a transaction, which is committed if fn returns
+// nil and rolled back otherwise. Sources which keep a row of their own
+// next to each item, e.g. an earthquake's, write both in one, so that
+// neither is left without the other.
+func InTransaction(database_url string, fn func(tx pgx.Tx) error) error {
+	conn, err := pgx.Connect(context.Background(), database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Printf("Error starting transaction: %v\n", err)
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Printf("Error committing transaction: %v\n", err)
+		return err
+	}
+	return nil
+}
+
+// InsertSource is Save, within a transaction
+func InsertSource(tx pgx.Tx, source types.ExpandedSource) error {
+	date, err := time.Parse(time.RFC3339, source.Date)
+	if err != nil {
+		log.Printf("Error parsing date %v: %v\n", source.Date, err)
+		return err
+	}
+	// Set when the date was read off the article, rather than the feed
+	var original_date *time.Time
+	if source.OriginalDate != "" {
+		parsed, err := time.Parse(time.RFC3339, source.OriginalDate)
+		if err == nil {
+			original_date = &parsed
+		}
+	}
+	var snapshot_id *int64
+	if source.SnapshotID != 0 {
+		snapshot_id = &source.SnapshotID
+	}
+
+	tag, err := tx.Exec(context.Background(), `
+        INSERT INTO sources (title, link, date, summary, importance_bool, importance_reasoning, importance_prompt, original_date, date_method, snapshot_id, tags)
+        VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10, $11)
+        ON CONFLICT (link) DO NOTHING
+    `, source.Title, source.Link, date, source.Summary, source.ImportanceBool, source.ImportanceReasoning, source.ImportancePrompt, original_date, source.DateMethod, snapshot_id, source.Tags)
+
+	if err != nil {
+		log.Printf("Error saving source to database: %v\n", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		log.Printf("Source already saved: %v\n", source.Link)
+		return ErrAlreadySaved
+	}
+	log.Printf("Saved source: %v\n", source.Title)
+	return nil
+}
+
+// A Revision brings an item which we follow as it evolves, e.g. an
+// earthquake, up to date. One which is Worse is marked unprocessed, so
+// that the client shows it again, with Reasoning as its new importance
+// reasoning.
+type Revision struct {
+	Link      string
+	Title     string
+	Summary   string
+	Worse     bool
+	Reasoning string
+}
+
+// UpdateSource applies a revision to an item in sources, within a
+// transaction
+func UpdateSource(tx pgx.Tx, revision Revision) error {
+	_, err := tx.Exec(context.Background(), `
+        UPDATE sources SET title = $1, summary = $2,
+            importance_reasoning = CASE WHEN $4 THEN $5 ELSE importance_reasoning END,
+            processed = CASE WHEN $4 THEN FALSE ELSE processed END
+        WHERE link = $3
+    `, revision.Title, revision.Summary, revision.Link, revision.Worse, revision.Reasoning)
+	if err != nil {
+		log.Printf("Error updating source: %v\n", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package pubdate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+/*
+Finds when an article was published, for sources which don't give us a
+trustworthy date (e.g. links cited from Wikipedia's current events). In
+order of preference:
+
+1. <meta> tags, like article:published_time
+2. JSON-LD datePublished
+3. <time datetime="..."> elements
+4. Dates in the url, like /2025/02/10/ or /2025-02/10/
+*/
+
+type Result struct {
+	Date   time.Time
+	Method string // e.g. "meta:article:published_time", "json-ld", "time", "url"
+}
+
+var metaSelectors = []struct {
+	selector string
+	method   string
+}{
+	{`meta[property="article:published_time"]`, "meta:article:published_time"},
+	{`meta[itemprop="datePublished"]`, "meta:itemprop:datePublished"},
+	{`meta[property="og:published_time"]`, "meta:og:published_time"},
+	{`meta[name="pubdate"]`, "meta:pubdate"},
+	{`meta[name="publishdate"]`, "meta:publishdate"},
+	{`meta[name="parsely-pub-date"]`, "meta:parsely-pub-date"},
+	{`meta[name="DC.date.issued"]`, "meta:DC.date.issued"},
+	{`meta[name="sailthru.date"]`, "meta:sailthru.date"},
+	{`meta[name="date"]`, "meta:date"},
+}
+
+var layouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05.000Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"20060102150405",
+	"20060102",
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"January 2, 2006 3:04 PM MST",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+}
+
+// Dates written out in Chinese come from mainland sites, and are in Beijing
+// time rather than UTC
+var chinaTime = time.FixedZone("CST", 8*3600)
+
+var chineseLayouts = []string{
+	"2006年01月02日 15:04",
+	"2006年01月02日",
+	"2006年1月2日",
+}
+
+// Parse tries the date formats commonly found in article metadata
+func Parse(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, errors.New("Empty date")
+	}
+	for _, layout := range layouts {
+		date, err := time.Parse(layout, raw)
+		if err == nil && isPlausible(date) {
+			return date, nil
+		}
+	}
+	for _, layout := range chineseLayouts {
+		date, err := time.ParseInLocation(layout, raw, chinaTime)
+		if err == nil && isPlausible(date) {
+			return date, nil
+		}
+	}
+	return time.Time{}, errors.New("Unrecognized date format: " + raw)
+}
+
+// Dates from before the web, or from the future, are parsing mistakes
+func isPlausible(date time.Time) bool {
+	return date.Year() >= 1995 && date.Before(time.Now().Add(48*time.Hour))
+}
+
+var urlPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`/(\d{4})-(\d{2})/(\d{2})/`),        // mil.gmw.cn/2025-02/10/content_37841910.htm
+	regexp.MustCompile(`/(\d{4})/(\d{1,2})/(\d{1,2})/`),    // /2025/02/10/slug
+	regexp.MustCompile(`/(\d{4})-(\d{2})-(\d{2})[/_-]`),    // /2025-02-10/slug
+	regexp.MustCompile(`[/_-](\d{4})(\d{2})(\d{2})[/_.-]`), // /20250210/slug, -20250210.html
+	regexp.MustCompile(`/(\d{4})(\d{2})/(\d{2})/`),         // /202502/10/slug
+	regexp.MustCompile(`/(\d{4})/(\d{2})(\d{2})/`),         // /2025/0210/slug
+	regexp.MustCompile(`/(\d{4})/([a-z]{3})/(\d{1,2})/`),   // /2025/feb/10/slug
+}
+
+func FromURL(link string) (time.Time, bool) {
+	for _, pattern := range urlPatterns {
+		matches := pattern.FindStringSubmatch(strings.ToLower(link))
+		if len(matches) != 4 {
+			continue
+		}
+		month := matches[2]
+		if len(month) == 1 {
+			month = "0" + month
+		}
+		day := matches[3]
+		if len(day) == 1 {
+			day = "0" + day
+		}
+		layout := "2006-01-02"
+		if len(month) == 3 {
+			layout = "2006-Jan-02"
+		}
+		date, err := time.Parse(layout, matches[1]+"-"+month+"-"+day)
+		if err == nil && isPlausible(date) {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// FromDocument returns the raw publication date in a page's metadata, and
+// where it was found
+func FromDocument(doc *goquery.Document) (string, string) {
+	for _, m := range metaSelectors {
+		content, _ := doc.Find(m.selector).First().Attr("content")
+		if strings.TrimSpace(content) != "" {
+			return strings.TrimSpace(content), m.method
+		}
+	}
+
+	date_published := ""
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		var data any
+		if json.Unmarshal([]byte(s.Text()), &data) != nil {
+			return true
+		}
+		date_published = findDatePublished(data)
+		return date_published == ""
+	})
+	if date_published != "" {
+		return date_published, "json-ld"
+	}
+
+	time_raw := ""
+	doc.Find("time").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		datetime, _ := s.Attr("datetime")
+		raw := strings.TrimSpace(datetime)
+		if raw == "" {
+			// e.g. <time pubdate>2025-02-10</time>
+			raw = strings.TrimSpace(s.Text())
+		}
+		if _, err := Parse(raw); err == nil {
+			time_raw = raw
+			return false
+		}
+		return true
+	})
+	if time_raw != "" {
+		return time_raw, "time"
+	}
+	return "", ""
+}
+
+func findDatePublished(data any) string {
+	switch v := data.(type) {
+	case []any:
+		for _, item := range v {
+			if found := findDatePublished(item); found != "" {
+				return found
+			}
+		}
+	case map[string]any:
+		if date, ok := v["datePublished"].(string); ok && date != "" {
+			return date
+		}
+		if graph, ok := v["@graph"]; ok {
+			return findDatePublished(graph)
+		}
+	}
+	return ""
+}
+
+// Resolve turns the raw date found by FromDocument into a time, falling
+// back on the url
+func Resolve(raw string, method string, link string) (Result, bool) {
+	if raw != "" {
+		date, err := Parse(raw)
+		if err == nil {
+			return Result{Date: date, Method: method}, true
+		}
+		log.Printf("Could not parse %v date %q", method, raw)
+	}
+	date, ok := FromURL(link)
+	if ok {
+		return Result{Date: date, Method: "url"}, true
+	}
+	return Result{}, false
+}
+
+// Extract finds the publication date of a page we've already fetched
+func Extract(page []byte, link string) (Result, bool) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		log.Printf("Error parsing html for %v: %v", link, err)
+		return Resolve("", "", link)
+	}
+	raw, method := FromDocument(doc)
+	return Resolve(raw, method, link)
+}
@@ -0,0 +1,58 @@
+package main
+
+/*
+Saves a real article as a fixture for make check-readability: the page as
+the extractor sees it, i.e. transcoded to UTF-8, as
+lib/readability/testdata/NAME.html, and what readability.js makes of the
+same url, as NAME.readability.txt, so that the local extractor can be
+compared against it. Needs READABILITY_URL in .env.
+
+An .expected file with the url is started if there isn't one; add title,
+byline, contains and excludes lines to it by hand.
+
+Usage: make readability-fixture NAME=reuters-quake URL=https://...
+*/
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"git.nunosempere.com/NunoSempere/news/lib/readability"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	if len(os.Args) != 3 || os.Args[1] == "" || os.Args[2] == "" {
+		fmt.Println("Usage: make readability-fixture NAME=... URL=...")
+		os.Exit(1)
+	}
+	name, article_url := os.Args[1], os.Args[2]
+	godotenv.Load()
+
+	page, err := web.Get(article_url)
+	if err != nil {
+		fmt.Printf("Error fetching %v: %v\n", article_url, err)
+		os.Exit(1)
+	}
+	reference, err := readability.GetReadabilityOutput(article_url)
+	if err != nil {
+		fmt.Printf("Error getting readability.js output for %v: %v\n", article_url, err)
+		os.Exit(1)
+	}
+
+	base := filepath.Join("lib/readability/testdata", name)
+	if err := os.WriteFile(base+".html", page, 0644); err != nil {
+		fmt.Printf("Error saving page: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(base+".readability.txt", []byte(reference), 0644); err != nil {
+		fmt.Printf("Error saving readability.js output: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := os.Stat(base + ".expected"); os.IsNotExist(err) {
+		os.WriteFile(base+".expected", []byte("url: "+article_url+"\n"), 0644)
+	}
+	fmt.Printf("Saved %v.html and %v.readability.txt\n", base, base)
+}
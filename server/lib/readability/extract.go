@@ -0,0 +1,488 @@
+package readability
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"git.nunosempere.com/NunoSempere/news/lib/pubdate"
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+/*
+A pure-Go main content extractor, loosely following Mozilla's readability.js:
+
+1. Read metadata (title, byline, publish date) before anything is removed.
+2. Strip scripts, navigation, and elements whose class/id look like boilerplate.
+3. Score every paragraph-like node by length and commas, and give those
+   points to its ancestors.
+4. Penalise candidates by their link density, and pick the best one, plus
+   any siblings that look like they are part of the same article.
+*/
+
+type Article struct {
+	Title           string
+	Byline          string
+	PublishedTime   string // raw, as found on the page
+	PublishedMethod string // where PublishedTime was found, see lib/pubdate
+	SiteName        string
+	Excerpt         string
+	Content         string
+	Paywalled       bool   // the page marks itself as not free to read
+	TitleOnly       bool   // we couldn't get past a paywall; Content is just the lede
+	Url             string // what we actually fetched, e.g. a mirror or archive
+	Html            []byte // the raw page, for lib/snapshots
+}
+
+var (
+	unlikelyCandidates = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cover-wrap|disqus|extra|footer|gdpr|header|legends|menu|related|remark|replies|rss|shoutbox|sidebar|skyscraper|social|sponsor|supplemental|ad-break|agegate|pagination|pager|popup|share|newsletter|subscribe|promo|cookie|consent|modal|outbrain|taboola|nav`)
+	maybeCandidate     = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow|story|text`)
+	positiveClass      = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+	negativeClass      = regexp.MustCompile(`(?i)-ad-|hidden|^hid$| hid$| hid |^hid |banner|combx|comment|com-|contact|foot|footer|footnote|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+	bylineClass        = regexp.MustCompile(`(?i)byline|author|dateline|writtenby|p-author`)
+	whitespace         = regexp.MustCompile(`\s+`)
+)
+
+const (
+	minParagraphLength = 25
+	minArticleLength   = 200
+)
+
+var blockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "dd": true, "div": true,
+	"dl": true, "dt": true, "figure": true, "footer": true, "form": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "header": true, "hr": true, "li": true,
+	"main": true, "ol": true, "p": true, "pre": true, "section": true, "table": true, "td": true,
+	"th": true, "tr": true, "ul": true, "br": true,
+}
+
+// Extract pulls the main article text and metadata out of a page
+func Extract(page []byte, page_url string) (Article, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		log.Printf("Error parsing html for %v: %v", page_url, err)
+		return Article{}, err
+	}
+
+	article := extractMetadata(doc)
+
+	removeBoilerplate(doc)
+	top, scores := findTopCandidate(doc)
+	var content string
+	if top == nil {
+		content = renderText(doc.Find("body").Nodes)
+	} else {
+		content = renderText(collectArticleNodes(top, scores))
+	}
+	article.Content = content
+
+	if utf8.RuneCountInString(content) < minArticleLength {
+		return article, errors.New("Extracted content too short")
+	}
+	return article, nil
+}
+
+/* Metadata */
+
+func extractMetadata(doc *goquery.Document) Article {
+	var article Article
+	json_ld := findJSONLDArticle(doc)
+
+	article.Title = firstNonEmpty(
+		metaContent(doc, `meta[property="og:title"]`),
+		metaContent(doc, `meta[name="twitter:title"]`),
+		jsonString(json_ld["headline"]),
+		doc.Find("title").First().Text(),
+		doc.Find("h1").First().Text(),
+	)
+
+	article.Byline = firstNonEmpty(
+		metaContent(doc, `meta[name="author"]`),
+		jsonAuthor(json_ld["author"]),
+		shortText(doc.Find(`[rel="author"], [itemprop="author"]`).First()),
+		bylineFromClass(doc),
+	)
+	if strings.HasPrefix(article.Byline, "http") {
+		// article:author is often a profile url, which isn't a byline
+		article.Byline = ""
+	}
+
+	article.PublishedTime, article.PublishedMethod = pubdate.FromDocument(doc)
+
+	switch free := json_ld["isAccessibleForFree"].(type) {
+	case bool:
+		article.Paywalled = !free
+	case string:
+		article.Paywalled = strings.EqualFold(free, "false")
+	}
+
+	article.SiteName = metaContent(doc, `meta[property="og:site_name"]`)
+	article.Excerpt = firstNonEmpty(
+		metaContent(doc, `meta[property="og:description"]`),
+		metaContent(doc, `meta[name="description"]`),
+	)
+	return article
+}
+
+func metaContent(doc *goquery.Document, selector string) string {
+	content, _ := doc.Find(selector).First().Attr("content")
+	return strings.TrimSpace(content)
+}
+
+func firstNonEmpty(options ...string) string {
+	for _, option := range options {
+		option = strings.TrimSpace(whitespace.ReplaceAllString(option, " "))
+		if option != "" {
+			return option
+		}
+	}
+	return ""
+}
+
+func shortText(s *goquery.Selection) string {
+	text := strings.TrimSpace(whitespace.ReplaceAllString(s.Text(), " "))
+	if utf8.RuneCountInString(text) > 100 {
+		return ""
+	}
+	return text
+}
+
+func bylineFromClass(doc *goquery.Document) string {
+	byline := ""
+	doc.Find("[class], [id]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		class, _ := s.Attr("class")
+		id, _ := s.Attr("id")
+		if !bylineClass.MatchString(class + " " + id) {
+			return true
+		}
+		byline = shortText(s)
+		return byline == ""
+	})
+	return byline
+}
+
+// Finds the first JSON-LD object that looks like an article, looking
+// inside arrays and @graph containers
+func findJSONLDArticle(doc *goquery.Document) map[string]any {
+	var found map[string]any
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		var data any
+		err := json.Unmarshal([]byte(s.Text()), &data)
+		if err != nil {
+			// Not an error worth logging; lots of sites ship invalid JSON-LD
+			return true
+		}
+		found = searchJSONLD(data)
+		return found == nil
+	})
+	if found == nil {
+		return map[string]any{}
+	}
+	return found
+}
+
+func searchJSONLD(data any) map[string]any {
+	switch v := data.(type) {
+	case []any:
+		for _, item := range v {
+			if found := searchJSONLD(item); found != nil {
+				return found
+			}
+		}
+	case map[string]any:
+		if graph, ok := v["@graph"]; ok {
+			if found := searchJSONLD(graph); found != nil {
+				return found
+			}
+		}
+		if _, ok := v["datePublished"]; ok {
+			return v
+		}
+		if strings.HasSuffix(jsonString(v["@type"]), "Article") {
+			return v
+		}
+	}
+	return nil
+}
+
+func jsonString(v any) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []any:
+		if len(s) > 0 {
+			return jsonString(s[0])
+		}
+	}
+	return ""
+}
+
+func jsonAuthor(v any) string {
+	switch a := v.(type) {
+	case string:
+		return a
+	case map[string]any:
+		return jsonString(a["name"])
+	case []any:
+		var names []string
+		for _, item := range a {
+			if name := jsonAuthor(item); name != "" {
+				names = append(names, name)
+			}
+		}
+		return strings.Join(names, ", ")
+	}
+	return ""
+}
+
+/* Boilerplate removal */
+
+func removeBoilerplate(doc *goquery.Document) {
+	doc.Find("script, style, noscript, iframe, form, svg, button, input, select, textarea, nav, footer, aside, template").Remove()
+
+	// Headers sometimes wrap the whole article, so only drop small ones
+	doc.Find("header").Each(func(_ int, s *goquery.Selection) {
+		if s.Find("p").Length() < 3 {
+			s.Remove()
+		}
+	})
+
+	doc.Find("body *").Each(func(_ int, s *goquery.Selection) {
+		tag := goquery.NodeName(s)
+		if tag == "article" || tag == "main" || tag == "a" {
+			return
+		}
+		class, _ := s.Attr("class")
+		id, _ := s.Attr("id")
+		match_string := class + " " + id
+		if unlikelyCandidates.MatchString(match_string) && !maybeCandidate.MatchString(match_string) {
+			s.Remove()
+			return
+		}
+		if hidden, _ := s.Attr("aria-hidden"); hidden == "true" {
+			s.Remove()
+		}
+	})
+}
+
+/* Scoring */
+
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	for _, attr := range n.Attr {
+		if attr.Key != "class" && attr.Key != "id" {
+			continue
+		}
+		if negativeClass.MatchString(attr.Val) {
+			weight -= 25
+		}
+		if positiveClass.MatchString(attr.Val) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+func initialScore(n *html.Node) float64 {
+	score := classWeight(n)
+	switch n.Data {
+	case "article", "main":
+		score += 10
+	case "div", "section":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+	return score
+}
+
+func hasBlockChildren(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && (blockTags[c.Data] || hasBlockChildren(c)) {
+			return true
+		}
+	}
+	return false
+}
+
+func isParagraphLike(n *html.Node) bool {
+	switch n.Data {
+	case "p", "pre", "blockquote":
+		return true
+	case "div", "section", "td":
+		return !hasBlockChildren(n)
+	}
+	return false
+}
+
+func nodeText(n *html.Node) string {
+	return strings.TrimSpace(whitespace.ReplaceAllString(goquery.NewDocumentFromNode(n).Text(), " "))
+}
+
+func linkDensity(n *html.Node) float64 {
+	s := goquery.NewDocumentFromNode(n).Selection
+	text_length := utf8.RuneCountInString(nodeText(n))
+	if text_length == 0 {
+		return 0
+	}
+	link_length := 0
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		link_length += utf8.RuneCountInString(strings.TrimSpace(whitespace.ReplaceAllString(a.Text(), " ")))
+	})
+	return float64(link_length) / float64(text_length)
+}
+
+func countCommas(text string) int {
+	return strings.Count(text, ",") + strings.Count(text, "，") + strings.Count(text, "、")
+}
+
+func findTopCandidate(doc *goquery.Document) (*html.Node, map[*html.Node]float64) {
+	scores := map[*html.Node]float64{}
+	var candidates []*html.Node
+
+	doc.Find("body *").Each(func(_ int, s *goquery.Selection) {
+		n := s.Nodes[0]
+		if !isParagraphLike(n) {
+			return
+		}
+		text := nodeText(n)
+		text_length := utf8.RuneCountInString(text)
+		if text_length < minParagraphLength {
+			return
+		}
+		score := 1 + float64(countCommas(text)) + math.Min(float64(text_length/100), 3)
+
+		ancestor := n.Parent
+		for level := 0; level < 3 && ancestor != nil && ancestor.Type == html.ElementNode; level++ {
+			if _, ok := scores[ancestor]; !ok {
+				scores[ancestor] = initialScore(ancestor)
+				candidates = append(candidates, ancestor)
+			}
+			divider := 1.0
+			if level == 1 {
+				divider = 2
+			} else if level > 1 {
+				divider = float64(level * 3)
+			}
+			scores[ancestor] += score / divider
+			ancestor = ancestor.Parent
+		}
+	})
+
+	var top *html.Node
+	top_score := math.Inf(-1)
+	for _, candidate := range candidates {
+		scores[candidate] *= 1 - linkDensity(candidate)
+		if scores[candidate] > top_score {
+			top = candidate
+			top_score = scores[candidate]
+		}
+	}
+	return top, scores
+}
+
+// Collects the top candidate and any siblings which look like part of the
+// same article, e.g. paragraphs split up by ads or images
+func collectArticleNodes(top *html.Node, scores map[*html.Node]float64) []*html.Node {
+	if top.Parent == nil {
+		return []*html.Node{top}
+	}
+	threshold := math.Max(10, scores[top]*0.2)
+	var nodes []*html.Node
+	for sibling := top.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+		if sibling.Type != html.ElementNode {
+			continue
+		}
+		if sibling == top {
+			nodes = append(nodes, sibling)
+			continue
+		}
+		if score, ok := scores[sibling]; ok && score+classWeight(top)*0.2 >= threshold {
+			nodes = append(nodes, sibling)
+			continue
+		}
+		if sibling.Data == "p" {
+			text := nodeText(sibling)
+			text_length := utf8.RuneCountInString(text)
+			density := linkDensity(sibling)
+			if text_length > 80 && density < 0.25 {
+				nodes = append(nodes, sibling)
+			} else if text_length > 0 && density == 0 && strings.HasSuffix(text, ".") {
+				nodes = append(nodes, sibling)
+			}
+		}
+	}
+	for _, n := range nodes {
+		cleanConditionally(n)
+	}
+	return nodes
+}
+
+// Drops link lists, share bars and similar leftovers from within the article
+func cleanConditionally(root *html.Node) {
+	s := goquery.NewDocumentFromNode(root).Selection
+	s.Find("div, section, ul, ol, table").Each(func(_ int, t *goquery.Selection) {
+		n := t.Nodes[0]
+		text := nodeText(n)
+		text_length := utf8.RuneCountInString(text)
+		density := linkDensity(n)
+		weight := classWeight(n)
+		switch {
+		case text_length == 0:
+			t.Remove()
+		case weight < 0:
+			t.Remove()
+		case density > 0.5:
+			t.Remove()
+		case density > 0.33 && text_length < 200 && countCommas(text) < 10:
+			t.Remove()
+		}
+	})
+}
+
+/* Rendering */
+
+// Renders nodes as plain text, with a blank line between blocks
+func renderText(nodes []*html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.WriteString(n.Data)
+		case html.ElementNode, html.DocumentNode:
+			is_block := blockTags[n.Data]
+			if is_block {
+				b.WriteString("\n\n")
+			}
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+			if is_block {
+				b.WriteString("\n\n")
+			}
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+
+	var paragraphs []string
+	for _, paragraph := range strings.Split(b.String(), "\n\n") {
+		paragraph = strings.TrimSpace(whitespace.ReplaceAllString(paragraph, " "))
+		if paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
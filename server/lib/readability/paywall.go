@@ -0,0 +1,68 @@
+package readability
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+)
+
+var paywallPhrases = regexp.MustCompile(`(?i)subscribe to (continue|keep) reading|to continue reading|already a subscriber|subscribers only|for subscribers|create a free account|sign in to read|this article is for|become a (member|subscriber)|unlock this article|start your free trial`)
+
+// Pages cut short by a paywall still have a title and a lede, but not
+// much beyond that
+const maxPaywallStubLength = 1500
+
+func IsPaywallStub(article Article) bool {
+	length := utf8.RuneCountInString(article.Content)
+	if length >= maxPaywallStubLength {
+		return false
+	}
+	return article.Paywalled || length < minArticleLength || paywallPhrases.MatchString(article.Content)
+}
+
+// The lede is the description the page gives of itself, or failing that, its
+// first paragraph
+func Lede(article Article) string {
+	if article.Excerpt != "" {
+		return article.Excerpt
+	}
+	first_paragraph, _, _ := strings.Cut(article.Content, "\n\n")
+	if paywallPhrases.MatchString(first_paragraph) {
+		return ""
+	}
+	return first_paragraph
+}
+
+type waybackAvailability struct {
+	ArchivedSnapshots struct {
+		Closest struct {
+			Available bool   `json:"available"`
+			Url       string `json:"url"`
+		} `json:"closest"`
+	} `json:"archived_snapshots"`
+}
+
+// GetArchiveURL looks up the closest Wayback Machine snapshot of a page
+func GetArchiveURL(article_url string) (string, error) {
+	response, err := web.Get("https://archive.org/wayback/available?url=" + url.QueryEscape(article_url))
+	if err != nil {
+		return "", err
+	}
+	var availability waybackAvailability
+	err = json.Unmarshal(response, &availability)
+	if err != nil {
+		log.Printf("Error unmarshalling wayback response: %v", err)
+		return "", err
+	}
+	closest := availability.ArchivedSnapshots.Closest
+	if !closest.Available || closest.Url == "" {
+		return "", errors.New("No archived snapshot available")
+	}
+	return closest.Url, nil
+}
@@ -1,17 +1,24 @@
 package readability
 
 import (
+	"bytes"
 	"errors"
+	"git.nunosempere.com/NunoSempere/news/lib/hosts"
 	"git.nunosempere.com/NunoSempere/news/lib/web"
 	"log"
-	"net/url"
-	"net/http"
+	"os"
 	"strings"
 	"github.com/PuerkitoBio/goquery"
 )
 
+// Optional fallback: a remote readability.js service, configured with
+// READABILITY_URL, e.g. https://trastos.nunosempere.com/readability
 func GetReadabilityOutput(article_url string) (string, error) {
-	readability_url := "https://trastos.nunosempere.com/readability?url=" + article_url // url must start with https
+	readability_service := os.Getenv("READABILITY_URL")
+	if readability_service == "" {
+		return "", errors.New("No remote readability service configured")
+	}
+	readability_url := readability_service + "?url=" + article_url // url must start with https
 	readability_response, err := web.Get(readability_url)
 	if err != nil {
 		return "", err
@@ -25,23 +32,14 @@ func GetReadabilityOutput(article_url string) (string, error) {
 	return readability_result, nil
 }
 
+// ReplaceWithOSFrontend returns the url on the host's first configured
+// mirror (e.g. www.reuters.com => neuters.de), or the url itself if none
 func ReplaceWithOSFrontend(u string) (string, error) {
-	// Parse the URL
-	parsedURL, err := url.Parse(u)
-	if err != nil {
-		log.Printf("Error parsing url to check for open source alternatives: %v", err)
-		return "", err
-	}
-
-	// Check if the host is bad_domain.com
-	if parsedURL.Host == "www.reuters.com" {
-		// Replace the host with open_source_alternative.net
-		parsedURL.Host = "neuters.de"
-
-		// Return the updated URL as a string
-		return parsedURL.String(), nil
+	for _, action := range hosts.ActionsFor(u) {
+		if action.Type == hosts.Mirror {
+			return hosts.MirrorURL(u, action.Host)
+		}
 	}
-	// Return the original URL if no replacement is necessary
 	return u, nil
 }
 
@@ -52,13 +50,12 @@ func ExtractTitle(url string) string {
 	if err == nil {
 		url_for_title = oss_url
 	}
-	resp, err := http.Get(url_for_title)
+	page, err := web.Get(url_for_title)
 	if err != nil {
 		return ""
 	}
-	defer resp.Body.Close()
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
 	if err != nil {
 		return ""
 	}
@@ -67,32 +64,123 @@ func ExtractTitle(url string) string {
 	return strings.TrimSpace(title)
 }
 
-func GetArticleContent(init_url string) (string, error) {
-	req_url := init_url
-	os_url, err0 := ReplaceWithOSFrontend(init_url)
-	if err0 == nil {
-		req_url = os_url
-	}
-	readable_text, err1 := GetReadabilityOutput(req_url)
+func fetchAndExtract(req_url string) (Article, error) {
 	log.Printf("Req url: %v", req_url)
-	if err1 != nil {
+	url_content, err := web.Get(req_url)
+	if err != nil {
+		return Article{}, err
+	}
+	article, err := Extract(url_content, req_url)
+	if err != nil {
+		log.Printf("Local extraction failed for %v: %v", req_url, err)
+	}
+	article.Url = req_url
+	article.Html = url_content
+	return article, err
+}
+
+func titleOnly(stub Article) Article {
+	lede := Lede(stub)
+	return Article{
+		Title:           stub.Title,
+		Byline:          stub.Byline,
+		PublishedTime:   stub.PublishedTime,
+		PublishedMethod: stub.PublishedMethod,
+		SiteName:        stub.SiteName,
+		Excerpt:         lede,
+		Content:         lede,
+		Paywalled:       true,
+		TitleOnly:       true,
+		Url:             stub.Url,
+		Html:            stub.Html,
+	}
+}
+
+// GetArticle fetches a page and extracts its main content, going through
+// the host's rules in config/hosts.yaml (mirrors, archives) until one gives
+// a full article. The remote readability service, if configured, is the last
+// resort. If all we ever got was a paywall stub, the article is returned
+// with TitleOnly set, so that it can be scored on its title and lede.
+func GetArticle(init_url string) (Article, error) {
+	var errs []error
+	var stub *Article
+	for _, action := range hosts.ActionsFor(init_url) {
+		var article Article
+		var err error
+		switch action.Type {
+		case hosts.Direct:
+			article, err = fetchAndExtract(init_url)
+		case hosts.Mirror:
+			var mirror_url string
+			mirror_url, err = hosts.MirrorURL(init_url, action.Host)
+			if err == nil {
+				article, err = fetchAndExtract(mirror_url)
+			}
+		case hosts.Archive:
+			var archive_url string
+			archive_url, err = GetArchiveURL(init_url)
+			if err == nil {
+				article, err = fetchAndExtract(archive_url)
+			}
+		case hosts.TitleOnly:
+			if stub == nil {
+				// Nothing fetched yet; the page itself should at least have a title and lede
+				article, err = fetchAndExtract(init_url)
+				if article.Title != "" {
+					stub = &article
+				}
+			}
+			if stub != nil && Lede(*stub) != "" {
+				log.Printf("Falling back to title and lede for %v", init_url)
+				return titleOnly(*stub), nil
+			}
+			// Without a lede there's nothing to score, so carry on to the
+			// next rule and the remote readability service
+			if err == nil {
+				err = errors.New("No title and lede to fall back on")
+			}
+			errs = append(errs, err)
+			continue
+		case hosts.Skip:
+			return Article{}, errors.New("Host is configured to be skipped")
+		default:
+			log.Printf("Unknown host rule action %q for %v", action.Type, init_url)
+			continue
+		}
 
-		url_content, err2 := web.Get(req_url)
-		if err2 != nil {
-			log.Println("Errors in both redability AND web.Get")
-			err := errors.Join(err1, err2)
-			return "", err
+		if err == nil && !IsPaywallStub(article) {
+			return article, nil
 		}
-		compressed_html, err2 := web.CompressHtml(string(url_content[:]))
-		if err2 != nil {
-			log.Println("Errors in both redability AND web.Get")
-			err := errors.Join(err1, err2)
-			return "", err
+		if article.Title != "" && stub == nil {
+			stub = &article
 		}
-		return compressed_html, nil
+		if err == nil {
+			log.Printf("Got a paywall stub (%s rule), trying the next one", action.Type)
+			err = errors.New("Paywall stub")
+		}
+		errs = append(errs, err)
+	}
 
+	readable_text, err := GetReadabilityOutput(init_url)
+	if err == nil {
+		return Article{Content: readable_text, Url: init_url}, nil
+	}
+	errs = append(errs, err)
+
+	if stub != nil && Lede(*stub) != "" {
+		log.Printf("Falling back to title and lede for %v", init_url)
+		return titleOnly(*stub), nil
+	}
+	log.Printf("Errors in all extraction methods for %v", init_url)
+	return Article{}, errors.Join(errs...)
+}
+
+func GetArticleContent(init_url string) (string, error) {
+	article, err := GetArticle(init_url)
+	if err != nil {
+		return "", err
 	}
-	return readable_text, nil
+	return article.Content, nil
 }
 
 /*
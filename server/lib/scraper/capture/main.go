@@ -0,0 +1,70 @@
+package main
+
+/*
+Saves a scraper definition's list page, as the scraper sees it, i.e.
+transcoded to UTF-8, as lib/scraper/testdata/FILE.html, for make
+scrape-test. Prints how many items the definition finds on it, so that a
+page which has changed under the selectors shows up straight away.
+
+Usage: make scrape-fixture NAME="DoD releases" FILE=dod-releases
+*/
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"git.nunosempere.com/NunoSempere/news/lib/scraper"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+	"gopkg.in/yaml.v3"
+)
+
+func main() {
+	if len(os.Args) != 3 || os.Args[1] == "" || os.Args[2] == "" {
+		fmt.Println("Usage: make scrape-fixture NAME=... FILE=...")
+		os.Exit(1)
+	}
+	name, file := os.Args[1], os.Args[2]
+
+	data, err := os.ReadFile("config/scrapers.yaml")
+	if err != nil {
+		fmt.Printf("Error reading config: %v\n", err)
+		os.Exit(1)
+	}
+	var config struct {
+		Scrapers []scraper.Definition `yaml:"scrapers"`
+	}
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		fmt.Printf("Error parsing config: %v\n", err)
+		os.Exit(1)
+	}
+	var definition *scraper.Definition
+	for i := range config.Scrapers {
+		if config.Scrapers[i].Name == name {
+			definition = &config.Scrapers[i]
+		}
+	}
+	if definition == nil {
+		fmt.Printf("No scraper named %q in config/scrapers.yaml\n", name)
+		os.Exit(1)
+	}
+
+	page, err := web.Get(definition.URL)
+	if err != nil {
+		fmt.Printf("Error fetching %v: %v\n", definition.URL, err)
+		os.Exit(1)
+	}
+	fixture_path := filepath.Join("lib/scraper/testdata", file+".html")
+	if err := os.WriteFile(fixture_path, page, 0644); err != nil {
+		fmt.Printf("Error saving page: %v\n", err)
+		os.Exit(1)
+	}
+
+	sources, _, err := definition.Extract(page, definition.URL)
+	if err != nil {
+		fmt.Printf("Saved %v, but extracting from it failed: %v\n", fixture_path, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Saved %v, with %d items\n", fixture_path, len(sources))
+}
@@ -0,0 +1,196 @@
+package scraper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/pubdate"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Declarative scrapers for sites without a feed. A definition says where the
+list of items is, and how to read each one, with CSS selectors:
+
+	url: https://www.example.gov/news/releases/
+	item: div.release               one per item on the page
+	title: h3                       text, relative to the item
+	link: {selector: h3 a, attr: href}
+	date: {selector: time, attr: datetime}
+	date_layout: 01/02/2006         a Go layout; lib/pubdate's formats otherwise
+	next: a.pager-next              the next page's link, if any
+	max_pages: 2                    how many pages to follow (default 1)
+
+A field given as a plain string is a selector whose text is used. Links
+default to the item's first <a href>, and titles to that link's text.
+*/
+
+// A CSS selector, relative to the item, and the attribute to read; its
+// text if Attr is empty
+type Field struct {
+	Selector string `yaml:"selector"`
+	Attr     string `yaml:"attr"`
+}
+
+func (f *Field) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		f.Selector = node.Value
+		return nil
+	}
+	type plain Field
+	return node.Decode((*plain)(f))
+}
+
+type Definition struct {
+	Name       string `yaml:"name"`
+	URL        string `yaml:"url"`
+	Item       string `yaml:"item"`
+	Title      Field  `yaml:"title"`
+	Link       Field  `yaml:"link"`
+	Date       Field  `yaml:"date"`
+	DateLayout string `yaml:"date_layout"`
+	Next       Field  `yaml:"next"`
+	MaxPages   int    `yaml:"max_pages"`
+}
+
+func (d Definition) Validate() error {
+	if d.Name == "" || d.URL == "" || d.Item == "" {
+		return errors.New("scraper definitions need a name, url and item selector")
+	}
+	if _, err := url.Parse(d.URL); err != nil {
+		return fmt.Errorf("scraper %q: invalid url: %v", d.Name, err)
+	}
+	for name, selector := range map[string]string{"item": d.Item, "title": d.Title.Selector, "link": d.Link.Selector, "date": d.Date.Selector, "next": d.Next.Selector} {
+		if selector == "" {
+			continue
+		}
+		if _, err := cascadia.Compile(selector); err != nil {
+			return fmt.Errorf("scraper %q: invalid %v selector %q: %v", d.Name, name, selector, err)
+		}
+	}
+	if d.MaxPages < 0 {
+		return fmt.Errorf("scraper %q: max_pages can't be negative", d.Name)
+	}
+	return nil
+}
+
+func (f Field) read(item *goquery.Selection) string {
+	selection := item
+	if f.Selector != "" {
+		selection = item.Find(f.Selector).First()
+	}
+	if f.Attr != "" {
+		value, _ := selection.Attr(f.Attr)
+		return strings.TrimSpace(value)
+	}
+	return strings.Join(strings.Fields(selection.Text()), " ")
+}
+
+func resolve(base *url.URL, link string) string {
+	if link == "" {
+		return ""
+	}
+	resolved, err := base.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return resolved.String()
+}
+
+func (d Definition) parseDate(raw string) (time.Time, error) {
+	if d.DateLayout != "" {
+		return time.Parse(d.DateLayout, raw)
+	}
+	return pubdate.Parse(raw)
+}
+
+// Extract runs a definition over one page. Dates are RFC3339, or empty if
+// the definition has none or it couldn't be parsed. next is the url of the
+// next page, if there is one.
+func (d Definition) Extract(page []byte, page_url string) (sources []types.Source, next string, err error) {
+	base, err := url.Parse(page_url)
+	if err != nil {
+		return nil, "", err
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		return nil, "", err
+	}
+
+	link_field := d.Link
+	if link_field.Selector == "" && link_field.Attr == "" {
+		link_field = Field{Selector: "a[href]", Attr: "href"}
+	} else if link_field.Attr == "" {
+		link_field.Attr = "href"
+	}
+	title_field := d.Title
+	if title_field.Selector == "" && title_field.Attr == "" {
+		title_field = Field{Selector: link_field.Selector}
+	}
+
+	doc.Find(d.Item).Each(func(_ int, item *goquery.Selection) {
+		link := resolve(base, link_field.read(item))
+		if link == "" {
+			return
+		}
+		source := types.Source{Title: title_field.read(item), Link: link}
+		if d.Date.Selector != "" || d.Date.Attr != "" {
+			raw := d.Date.read(item)
+			date, err := d.parseDate(raw)
+			if err == nil {
+				source.Date = date.Format(time.RFC3339)
+			} else if raw != "" {
+				log.Printf("Scraper %q: couldn't parse date %q: %v", d.Name, raw, err)
+			}
+		}
+		sources = append(sources, source)
+	})
+
+	if d.Next.Selector != "" {
+		next_field := d.Next
+		if next_field.Attr == "" {
+			next_field.Attr = "href"
+		}
+		next = resolve(base, next_field.read(doc.Selection))
+	}
+	return sources, next, nil
+}
+
+// Scrape fetches the definition's pages, following next links up to
+// max_pages, and returns their items without repeats, in page order
+func (d Definition) Scrape() ([]types.Source, error) {
+	var sources []types.Source
+	seen := map[string]bool{}
+	page_url := d.URL
+	for page := 0; page < max(d.MaxPages, 1) && page_url != ""; page++ {
+		body, err := web.Get(page_url)
+		if err != nil {
+			return sources, err
+		}
+		page_sources, next, err := d.Extract(body, page_url)
+		if err != nil {
+			log.Printf("Error parsing %v: %v", page_url, err)
+			return sources, err
+		}
+		for _, source := range page_sources {
+			if !seen[source.Link] {
+				seen[source.Link] = true
+				sources = append(sources, source)
+			}
+		}
+		if next == page_url {
+			break
+		}
+		page_url = next
+	}
+	return sources, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+/*
+Prints what a scraper definition in config/scrapers.yaml extracts from a
+saved copy of its list page, so that selectors can be written and fixed
+without hitting the site. Relative links are resolved against the
+definition's url.
+
+Usage: make scrape-test NAME="DoD releases" FIXTURE=lib/scraper/testdata/dod-releases.html
+
+Save a fresh copy of the page with make scrape-fixture.
+*/
+
+import (
+	"fmt"
+	"os"
+
+	"git.nunosempere.com/NunoSempere/news/lib/scraper"
+	"gopkg.in/yaml.v3"
+)
+
+func main() {
+	if len(os.Args) != 3 {
+		fmt.Println("Usage: scrape-test <definition name> <fixture.html>")
+		os.Exit(1)
+	}
+	name, fixture_path := os.Args[1], os.Args[2]
+
+	data, err := os.ReadFile("config/scrapers.yaml")
+	if err != nil {
+		fmt.Printf("Error reading config: %v\n", err)
+		os.Exit(1)
+	}
+	var config struct {
+		Scrapers []scraper.Definition `yaml:"scrapers"`
+	}
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		fmt.Printf("Error parsing config: %v\n", err)
+		os.Exit(1)
+	}
+
+	var definition *scraper.Definition
+	for i := range config.Scrapers {
+		if config.Scrapers[i].Name == name {
+			definition = &config.Scrapers[i]
+		}
+	}
+	if definition == nil {
+		fmt.Printf("No scraper named %q in config/scrapers.yaml\n", name)
+		os.Exit(1)
+	}
+	err = definition.Validate()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	page, err := os.ReadFile(fixture_path)
+	if err != nil {
+		fmt.Printf("Error reading fixture: %v\n", err)
+		os.Exit(1)
+	}
+	sources, next, err := definition.Extract(page, definition.URL)
+	if err != nil {
+		fmt.Printf("Error extracting: %v\n", err)
+		os.Exit(1)
+	}
+
+	for i, source := range sources {
+		fmt.Printf("#%d %v\n", i+1, source.Title)
+		fmt.Printf("   link: %v\n", source.Link)
+		if source.Date != "" {
+			fmt.Printf("   date: %v\n", source.Date)
+		}
+	}
+	fmt.Printf("\n%d items", len(sources))
+	if next != "" {
+		fmt.Printf(", next page: %v", next)
+	}
+	fmt.Println()
+	if len(sources) == 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+/*
+Inspects, prunes and re-scores the pages kept in page_snapshots.
+
+	show <snapshot id>: print a snapshot's url, fetch time and text
+	prune [-unlinked-days 30] [-linked-days 365]: apply the retention policy
+	rescore [-write] <source id>...: re-run summarization and the importance
+		check, with the prompt it was scored with, on the page a source was
+		originally scored on, and print the old and new verdicts. Paywalled
+		sources, which were scored on their title and lede, are only
+		re-checked. With -write, the sources row is updated.
+
+Usage: make prune-snapshots, or go run lib/snapshots/cmd/main.go <command>
+*/
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"git.nunosempere.com/NunoSempere/news/lib/readability"
+	"git.nunosempere.com/NunoSempere/news/lib/snapshots"
+	"github.com/jackc/pgx/v5"
+	"github.com/joho/godotenv"
+)
+
+func show(args []string, database_url string) {
+	if len(args) != 1 {
+		log.Fatal("Usage: show <snapshot id>")
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid snapshot id: %v", args[0])
+	}
+	snapshot, err := snapshots.Load(id, database_url)
+	if err != nil {
+		os.Exit(1)
+	}
+	fmt.Printf("Url: %s\nRequested url: %s\nFetched at: %s\nHtml: %d bytes\n\n%s\n",
+		snapshot.Url, snapshot.RequestedUrl, snapshot.FetchedAt, len(snapshot.Html), snapshot.Text)
+}
+
+func prune(args []string, database_url string) {
+	flags := flag.NewFlagSet("prune", flag.ExitOnError)
+	unlinked_days := flags.Int("unlinked-days", 30, "keep snapshots no source points to for this many days")
+	linked_days := flags.Int("linked-days", 365, "keep snapshots sources point to for this many days")
+	flags.Parse(args)
+
+	num_snapshots, num_blobs, err := snapshots.Prune(*unlinked_days, *linked_days, database_url)
+	if err != nil {
+		os.Exit(1)
+	}
+	log.Printf("Pruned %d snapshots and %d blobs", num_snapshots, num_blobs)
+}
+
+type scoredSource struct {
+	Title               string
+	Link                string
+	ImportanceBool      bool
+	ImportanceReasoning string
+	ImportancePrompt    string
+	Summary             string
+	SnapshotID          *int64
+}
+
+// Sources from before prompts were saved were scored with the default one
+const defaultPrompt = "default"
+
+// The summary which sources scored on their title and lede are saved with
+const ledeOnlyPrefix = "[Paywalled, lede only] "
+
+func rescoreSource(conn *pgx.Conn, source_id int64, write bool, openai_key string, database_url string) error {
+	var source scoredSource
+	err := conn.QueryRow(context.Background(), `
+		SELECT title, link, COALESCE(importance_bool, false), COALESCE(importance_reasoning, ''),
+			COALESCE(importance_prompt, $2), COALESCE(summary, ''), snapshot_id
+		FROM sources WHERE id = $1
+	`, source_id, defaultPrompt).Scan(&source.Title, &source.Link, &source.ImportanceBool, &source.ImportanceReasoning,
+		&source.ImportancePrompt, &source.Summary, &source.SnapshotID)
+	if err != nil {
+		return err
+	}
+	check_importance, exists := llm.ImportanceChecks[source.ImportancePrompt]
+	if !exists {
+		return fmt.Errorf("Source %d was scored with unknown prompt %q", source_id, source.ImportancePrompt)
+	}
+
+	summary := source.Summary
+	if !strings.HasPrefix(summary, ledeOnlyPrefix) {
+		summary, err = resummarize(source, openai_key, database_url)
+		if err != nil {
+			return err
+		}
+	}
+	existential_importance_snippet := "# " + source.Title + "\n\n" + summary
+	existential_importance_box, err := check_importance(existential_importance_snippet, openai_key)
+	if err != nil {
+		return err
+	}
+	if existential_importance_box == nil {
+		return fmt.Errorf("No importance verdict for source %d", source_id)
+	}
+
+	fmt.Printf("# %s (%s), %s prompt\n", source.Title, source.Link, source.ImportancePrompt)
+	fmt.Printf("Old: %t, %s\n", source.ImportanceBool, source.ImportanceReasoning)
+	fmt.Printf("New: %t, %s\n\n", existential_importance_box.ExistentialImportanceBool, existential_importance_box.ExistentialImportanceReasoning)
+
+	if !write {
+		return nil
+	}
+	_, err = conn.Exec(context.Background(), `
+		UPDATE sources SET summary = $1, importance_bool = $2, importance_reasoning = $3 WHERE id = $4
+	`, summary, existential_importance_box.ExistentialImportanceBool, existential_importance_box.ExistentialImportanceReasoning, source_id)
+	return err
+}
+
+// resummarize summarizes the page the source was scored on again
+func resummarize(source scoredSource, openai_key string, database_url string) (string, error) {
+	if source.SnapshotID == nil {
+		return "", fmt.Errorf("Source %v has no snapshot", source.Link)
+	}
+	snapshot, err := snapshots.Load(*source.SnapshotID, database_url)
+	if err != nil {
+		return "", err
+	}
+
+	// Prefer re-extracting from the html, so that extractor improvements
+	// are picked up too
+	text := snapshot.Text
+	if len(snapshot.Html) > 0 {
+		article, err := readability.Extract(snapshot.Html, snapshot.RequestedUrl)
+		if err == nil {
+			text = article.Content
+		}
+	}
+	if text == "" {
+		return "", fmt.Errorf("Snapshot %d has no text", snapshot.ID)
+	}
+	return llm.Summarize(text, openai_key)
+}
+
+func rescore(args []string, openai_key string, database_url string) {
+	flags := flag.NewFlagSet("rescore", flag.ExitOnError)
+	write := flags.Bool("write", false, "save the new summary and verdict to the sources row")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		log.Fatal("Usage: rescore [-write] <source id>...")
+	}
+
+	conn, err := pgx.Connect(context.Background(), database_url)
+	if err != nil {
+		log.Fatalf("Unable to connect to database: %v\n", err)
+	}
+	defer conn.Close(context.Background())
+
+	for _, arg := range flags.Args() {
+		source_id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			log.Printf("Invalid source id: %v", arg)
+			continue
+		}
+		err = rescoreSource(conn, source_id, *write, openai_key, database_url)
+		if err != nil {
+			log.Printf("Error rescoring source %d: %v", source_id, err)
+		}
+	}
+}
+
+func main() {
+	err := godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	openai_key := os.Getenv("OPENAI_KEY")
+	pg_database_url := os.Getenv("DATABASE_POOL_URL")
+
+	if len(os.Args) < 2 {
+		log.Fatal("Usage: main.go show|prune|rescore ...")
+	}
+	switch os.Args[1] {
+	case "show":
+		show(os.Args[2:], pg_database_url)
+	case "prune":
+		prune(os.Args[2:], pg_database_url)
+	case "rescore":
+		rescore(os.Args[2:], openai_key, pg_database_url)
+	default:
+		log.Fatalf("Unknown command: %v", os.Args[1])
+	}
+}
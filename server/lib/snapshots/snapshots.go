@@ -0,0 +1,231 @@
+package snapshots
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/jackc/pgx/v5"
+)
+
+/*
+Keeps the pages we fetch, so that if an article is later edited, paywalled or
+deleted we can still show forecasters the original, and re-run new prompts on
+it without refetching. Contents are gzipped and stored once per sha256 in
+page_blobs; page_snapshots records each fetch of a (canonical) url, and
+sources rows point at the snapshot they were scored on.
+*/
+
+type Snapshot struct {
+	ID           int64
+	Url          string
+	RequestedUrl string
+	FetchedAt    time.Time
+	Html         []byte
+	Text         string
+}
+
+var trackingParams = []string{"utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content", "fbclid", "gclid", "ocid", "cmpid"}
+
+// Canonicalize uses the page's <link rel="canonical">, if any, and otherwise
+// strips fragments and tracking parameters from the link
+func Canonicalize(link string, html []byte) string {
+	if len(html) > 0 {
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
+		if err == nil {
+			canonical, _ := doc.Find(`link[rel="canonical"]`).First().Attr("href")
+			if strings.HasPrefix(canonical, "http") {
+				link = canonical
+			}
+		}
+	}
+	parsed_url, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return link
+	}
+	parsed_url.Host = strings.ToLower(parsed_url.Host)
+	parsed_url.Fragment = ""
+	query := parsed_url.Query()
+	for _, param := range trackingParams {
+		query.Del(param)
+	}
+	parsed_url.RawQuery = query.Encode()
+	return parsed_url.String()
+}
+
+func hashOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func compress(data []byte) ([]byte, error) {
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	_, err := w.Write(data)
+	if err != nil {
+		return nil, err
+	}
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func saveBlob(conn *pgx.Conn, data []byte) (*string, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	hash := hashOf(data)
+	compressed, err := compress(data)
+	if err != nil {
+		log.Printf("Error compressing snapshot: %v", err)
+		return nil, err
+	}
+	_, err = conn.Exec(context.Background(), `
+		INSERT INTO page_blobs (hash, data, size) VALUES ($1, $2, $3)
+		ON CONFLICT (hash) DO NOTHING
+	`, hash, compressed, len(data))
+	if err != nil {
+		log.Printf("Error saving page blob: %v", err)
+		return nil, err
+	}
+	return &hash, nil
+}
+
+// Save stores the html of a page we fetched and the text we extracted from
+// it, and returns the snapshot's id, to be linked from the sources row
+func Save(requested_url string, html []byte, text string, database_url string) (int64, error) {
+	if len(html) == 0 && text == "" {
+		return 0, errors.New("Nothing to snapshot")
+	}
+	conn, err := pgx.Connect(context.Background(), database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return 0, err
+	}
+	defer conn.Close(context.Background())
+
+	html_hash, err := saveBlob(conn, html)
+	if err != nil {
+		return 0, err
+	}
+	text_hash, err := saveBlob(conn, []byte(text))
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = conn.QueryRow(context.Background(), `
+		INSERT INTO page_snapshots (url, requested_url, fetched_at, html_hash, text_hash)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, Canonicalize(requested_url, html), requested_url, time.Now(), html_hash, text_hash).Scan(&id)
+	if err != nil {
+		log.Printf("Error saving page snapshot: %v", err)
+		return 0, err
+	}
+	return id, nil
+}
+
+func loadBlob(conn *pgx.Conn, hash *string) ([]byte, error) {
+	if hash == nil {
+		return nil, nil
+	}
+	var compressed []byte
+	err := conn.QueryRow(context.Background(), `SELECT data FROM page_blobs WHERE hash = $1`, *hash).Scan(&compressed)
+	if err != nil {
+		log.Printf("Error loading page blob %v: %v", *hash, err)
+		return nil, err
+	}
+	data, err := decompress(compressed)
+	if err != nil {
+		log.Printf("Error decompressing page blob %v: %v", *hash, err)
+		return nil, err
+	}
+	return data, nil
+}
+
+func Load(id int64, database_url string) (Snapshot, error) {
+	snapshot := Snapshot{ID: id}
+	conn, err := pgx.Connect(context.Background(), database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return snapshot, err
+	}
+	defer conn.Close(context.Background())
+
+	var html_hash, text_hash *string
+	err = conn.QueryRow(context.Background(), `
+		SELECT url, requested_url, fetched_at, html_hash, text_hash FROM page_snapshots WHERE id = $1
+	`, id).Scan(&snapshot.Url, &snapshot.RequestedUrl, &snapshot.FetchedAt, &html_hash, &text_hash)
+	if err != nil {
+		log.Printf("Error loading page snapshot %v: %v", id, err)
+		return snapshot, err
+	}
+	snapshot.Html, err = loadBlob(conn, html_hash)
+	if err != nil {
+		return snapshot, err
+	}
+	text, err := loadBlob(conn, text_hash)
+	if err != nil {
+		return snapshot, err
+	}
+	snapshot.Text = string(text)
+	return snapshot, nil
+}
+
+// Retention policy: snapshots which no sources row points to are kept for
+// unlinked_days, and those which do for linked_days. Blobs go once no
+// snapshot references them.
+func Prune(unlinked_days int, linked_days int, database_url string) (int64, int64, error) {
+	conn, err := pgx.Connect(context.Background(), database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return 0, 0, err
+	}
+	defer conn.Close(context.Background())
+
+	tag, err := conn.Exec(context.Background(), `
+		DELETE FROM page_snapshots p
+		WHERE (
+			NOT EXISTS (SELECT 1 FROM sources s WHERE s.snapshot_id = p.id)
+			AND p.fetched_at < NOW() - make_interval(days => $1)
+		) OR p.fetched_at < NOW() - make_interval(days => $2)
+	`, unlinked_days, linked_days)
+	if err != nil {
+		log.Printf("Error pruning page snapshots: %v", err)
+		return 0, 0, err
+	}
+	num_snapshots := tag.RowsAffected()
+
+	tag, err = conn.Exec(context.Background(), `
+		DELETE FROM page_blobs b
+		WHERE NOT EXISTS (
+			SELECT 1 FROM page_snapshots p WHERE p.html_hash = b.hash OR p.text_hash = b.hash
+		)
+	`)
+	if err != nil {
+		log.Printf("Error pruning page blobs: %v", err)
+		return num_snapshots, 0, err
+	}
+	return num_snapshots, tag.RowsAffected(), nil
+}
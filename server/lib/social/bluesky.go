@@ -0,0 +1,151 @@
+package social
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+)
+
+// Bluesky's public AppView, <https://docs.bsky.app/docs/advanced-guides/api-directory>
+const blueskyAPI = "https://public.api.bsky.app/xrpc/"
+
+type blueskyPost struct {
+	URI    string `json:"uri"`
+	Author struct {
+		Handle string `json:"handle"`
+	} `json:"author"`
+	Record struct {
+		Text      string `json:"text"`
+		CreatedAt string `json:"createdAt"`
+		Facets    []struct {
+			Features []struct {
+				Type string `json:"$type"`
+				URI  string `json:"uri"`
+			} `json:"features"`
+		} `json:"facets"`
+	} `json:"record"`
+	Embed struct {
+		Type     string `json:"$type"`
+		External struct {
+			URI string `json:"uri"`
+		} `json:"external"`
+	} `json:"embed"`
+}
+
+type blueskyResponse struct {
+	// getAuthorFeed and getListFeed
+	Feed []struct {
+		Post   blueskyPost `json:"post"`
+		Reason *struct {
+			Type string `json:"$type"`
+		} `json:"reason"`
+	} `json:"feed"`
+	// searchPosts
+	Posts []blueskyPost `json:"posts"`
+}
+
+func (p blueskyPost) toPost() (Post, bool) {
+	// at://did:plc:xyz/app.bsky.feed.post/<rkey>
+	rkey := p.URI[strings.LastIndex(p.URI, "/")+1:]
+	if rkey == "" || p.Author.Handle == "" {
+		return Post{}, false
+	}
+	created_at, err := time.Parse(time.RFC3339, p.Record.CreatedAt)
+	if err != nil {
+		log.Printf("Error parsing post date %q: %v", p.Record.CreatedAt, err)
+		created_at = time.Now()
+	}
+	post := Post{
+		Network:   "bluesky",
+		URL:       "https://bsky.app/profile/" + p.Author.Handle + "/post/" + rkey,
+		Author:    p.Author.Handle,
+		Text:      p.Record.Text,
+		CreatedAt: created_at.UTC(),
+	}
+	for _, facet := range p.Record.Facets {
+		for _, feature := range facet.Features {
+			if feature.Type == "app.bsky.richtext.facet#link" {
+				post.Links = addLink(post.Links, feature.URI)
+			}
+		}
+	}
+	if p.Embed.Type == "app.bsky.embed.external#view" {
+		post.Links = addLink(post.Links, p.Embed.External.URI)
+	}
+	return post, true
+}
+
+// ParseBluesky reads a getAuthorFeed, getListFeed or searchPosts response
+func ParseBluesky(body []byte) ([]Post, error) {
+	var response blueskyResponse
+	err := json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, err
+	}
+	var posts []Post
+	for _, item := range response.Feed {
+		if item.Reason != nil {
+			continue // a repost
+		}
+		if post, ok := item.Post.toPost(); ok {
+			posts = append(posts, post)
+		}
+	}
+	for _, item := range response.Posts {
+		if post, ok := item.toPost(); ok {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+
+func getBluesky(method string, params url.Values) ([]Post, error) {
+	body, err := web.Get(blueskyAPI + method + "?" + params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	posts, err := ParseBluesky(body)
+	if err != nil {
+		log.Printf("Error parsing %v response: %v", method, err)
+		return nil, err
+	}
+	return posts, nil
+}
+
+func BlueskyAccount(handle string) ([]Post, error) {
+	return getBluesky("app.bsky.feed.getAuthorFeed", url.Values{"actor": {handle}, "filter": {"posts_no_replies"}, "limit": {"30"}})
+}
+
+// BlueskyList takes a list's at:// uri, or its bsky.app url if that has
+// the owner's did rather than their handle
+func BlueskyList(list string) ([]Post, error) {
+	uri, err := BlueskyListURI(list)
+	if err != nil {
+		return nil, err
+	}
+	return getBluesky("app.bsky.feed.getListFeed", url.Values{"list": {uri}, "limit": {"30"}})
+}
+
+func BlueskyHashtag(tag string) ([]Post, error) {
+	tag = strings.TrimPrefix(tag, "#")
+	return getBluesky("app.bsky.feed.searchPosts", url.Values{"q": {"#" + tag}, "tag": {tag}, "sort": {"latest"}, "limit": {"30"}})
+}
+
+// BlueskyListURI turns https://bsky.app/profile/did:plc:xyz/lists/abc into
+// at://did:plc:xyz/app.bsky.graph.list/abc
+func BlueskyListURI(list string) (string, error) {
+	if strings.HasPrefix(list, "at://") {
+		return list, nil
+	}
+	path := strings.TrimPrefix(list, "https://bsky.app/profile/")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if path == list || len(parts) != 3 || parts[1] != "lists" || !strings.HasPrefix(parts[0], "did:") {
+		return "", fmt.Errorf("expected an at:// uri or a bsky.app/profile/did:.../lists/... url, got: %q", list)
+	}
+	return "at://" + parts[0] + "/app.bsky.graph.list/" + parts[2], nil
+}
@@ -0,0 +1,156 @@
+package social
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+	"github.com/PuerkitoBio/goquery"
+)
+
+type mastodonStatus struct {
+	URL       string          `json:"url"`
+	CreatedAt string          `json:"created_at"`
+	Content   string          `json:"content"`
+	Reblog    *mastodonStatus `json:"reblog"`
+	Account   struct {
+		Acct string `json:"acct"`
+	} `json:"account"`
+	Card *struct {
+		URL string `json:"url"`
+	} `json:"card"`
+}
+
+// ParseMastodon reads a list of statuses, as returned by the account
+// statuses and hashtag timeline endpoints of the given instance
+func ParseMastodon(body []byte, instance string) ([]Post, error) {
+	var statuses []mastodonStatus
+	err := json.Unmarshal(body, &statuses)
+	if err != nil {
+		return nil, err
+	}
+	var posts []Post
+	for _, status := range statuses {
+		if status.Reblog != nil || status.URL == "" {
+			continue
+		}
+		created_at, err := time.Parse(time.RFC3339, status.CreatedAt)
+		if err != nil {
+			log.Printf("Error parsing post date %q: %v", status.CreatedAt, err)
+			created_at = time.Now()
+		}
+		// Local accounts come without their instance
+		author := status.Account.Acct
+		if !strings.Contains(author, "@") {
+			author += "@" + instance
+		}
+		post := Post{Network: "mastodon", URL: status.URL, Author: author, CreatedAt: created_at.UTC()}
+
+		// content is html, with mentions and hashtags as links too
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(status.Content))
+		if err != nil {
+			continue
+		}
+		doc.Find("br").ReplaceWithHtml("\n")
+		doc.Find("p").Each(func(_ int, p *goquery.Selection) {
+			p.AppendHtml("\n\n")
+		})
+		post.Text = strings.TrimSpace(doc.Text())
+		doc.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+			class, _ := a.Attr("class")
+			rel, _ := a.Attr("rel")
+			if strings.Contains(class, "mention") || strings.Contains(class, "hashtag") || strings.Contains(rel, "tag") {
+				return
+			}
+			href, _ := a.Attr("href")
+			post.Links = addLink(post.Links, href)
+		})
+		if status.Card != nil {
+			post.Links = addLink(post.Links, status.Card.URL)
+		}
+		posts = append(posts, post)
+	}
+	return posts, nil
+}
+
+func getMastodon(instance string, path string, params url.Values) ([]Post, error) {
+	body, err := web.Get("https://" + instance + path + "?" + params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	posts, err := ParseMastodon(body, instance)
+	if err != nil {
+		log.Printf("Error parsing %v response: %v", path, err)
+		return nil, err
+	}
+	return posts, nil
+}
+
+// Account ids don't change, so they're looked up once per instance
+var mastodonAccountIDs = map[string]string{}
+var mastodonAccountIDsMutex sync.Mutex
+
+func mastodonAccountID(user string, instance string) (string, error) {
+	acct := user + "@" + instance
+	mastodonAccountIDsMutex.Lock()
+	id, exists := mastodonAccountIDs[acct]
+	mastodonAccountIDsMutex.Unlock()
+	if exists {
+		return id, nil
+	}
+
+	body, err := web.Get("https://" + instance + "/api/v1/accounts/lookup?" + url.Values{"acct": {user}}.Encode())
+	if err != nil {
+		return "", err
+	}
+	var account struct {
+		ID string `json:"id"`
+	}
+	err = json.Unmarshal(body, &account)
+	if err != nil || account.ID == "" {
+		log.Printf("Error looking up %v: %v", acct, err)
+		return "", errors.New("Account not found: " + acct)
+	}
+	mastodonAccountIDsMutex.Lock()
+	mastodonAccountIDs[acct] = account.ID
+	mastodonAccountIDsMutex.Unlock()
+	return account.ID, nil
+}
+
+// SplitAcct splits user@instance, with or without a leading @
+func SplitAcct(acct string) (user string, instance string, err error) {
+	user, instance, found := strings.Cut(strings.TrimPrefix(acct, "@"), "@")
+	if !found || user == "" || instance == "" {
+		return "", "", fmt.Errorf("expected user@instance, got: %q", acct)
+	}
+	return user, instance, nil
+}
+
+// MastodonAccount takes user@instance, and asks that instance
+func MastodonAccount(acct string) ([]Post, error) {
+	user, instance, err := SplitAcct(acct)
+	if err != nil {
+		return nil, err
+	}
+	id, err := mastodonAccountID(user, instance)
+	if err != nil {
+		return nil, err
+	}
+	return getMastodon(instance, "/api/v1/accounts/"+id+"/statuses", url.Values{"exclude_replies": {"true"}, "exclude_reblogs": {"true"}, "limit": {"30"}})
+}
+
+// MastodonHashtag takes tag@instance. Each instance only knows about the
+// posts that have reached it, so big instances see the most.
+func MastodonHashtag(tag_at_instance string) ([]Post, error) {
+	tag, instance, err := SplitAcct(strings.TrimPrefix(tag_at_instance, "#"))
+	if err != nil {
+		return nil, err
+	}
+	return getMastodon(instance, "/api/v1/timelines/tag/"+url.PathEscape(tag), url.Values{"limit": {"30"}})
+}
@@ -0,0 +1,44 @@
+package social
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+/*
+Posts from Bluesky and Mastodon, through their public APIs, which need no
+key. Each function returns the newest posts first, without reposts, and
+with the links in each post pulled out so that the articles they point to
+can go through the usual pipeline.
+*/
+
+type Post struct {
+	Network   string // "bluesky" or "mastodon"
+	URL       string // the post's web page, which is also its id
+	Author    string // handle, or user@instance
+	Text      string
+	CreatedAt time.Time
+	Links     []string // to articles, not to other posts, profiles or hashtags
+}
+
+// Title is how the post is shown in the client
+func (p Post) Title() string {
+	text := strings.Join(strings.Fields(p.Text), " ")
+	if utf8.RuneCountInString(text) > 120 {
+		text = string([]rune(text)[:120]) + "…"
+	}
+	return "@" + p.Author + ": " + text
+}
+
+func addLink(links []string, link string) []string {
+	if !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
+		return links
+	}
+	for _, existing := range links {
+		if existing == link {
+			return links
+		}
+	}
+	return append(links, link)
+}
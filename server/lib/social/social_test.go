@@ -0,0 +1,120 @@
+package social
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+/*
+Runs the Bluesky and Mastodon parsers over the API responses recorded in
+lib/social/testdata, and checks each one against its .expected file, which
+has lines like
+
+	instance: the Mastodon instance the response came from
+	posts: how many posts should be parsed, after dropping reposts
+	post: the url of a post that must be there
+	author: the author of some post
+	link: a link that some post must have
+	no-link: a link, e.g. to a mention or hashtag, that no post may have
+	no-links                 if no post should have links
+	contains: text some post must have
+	excludes: text no post may have, e.g. from a repost
+
+Usage: make check-social, or go test ./lib/social
+*/
+
+func checkFixture(json_path string) []string {
+	body, err := os.ReadFile(json_path)
+	if err != nil {
+		return []string{fmt.Sprintf("reading fixture: %v", err)}
+	}
+	expected_path := strings.TrimSuffix(json_path, ".json") + ".expected"
+	expected, err := os.ReadFile(expected_path)
+	if err != nil {
+		return []string{fmt.Sprintf("reading %v: %v", expected_path, err)}
+	}
+	lines := strings.Split(string(expected), "\n")
+
+	var posts []Post
+	if strings.HasPrefix(filepath.Base(json_path), "mastodon") {
+		instance := ""
+		for _, line := range lines {
+			if value, found := strings.CutPrefix(line, "instance: "); found {
+				instance = value
+			}
+		}
+		posts, err = ParseMastodon(body, instance)
+	} else {
+		posts, err = ParseBluesky(body)
+	}
+	if err != nil {
+		return []string{fmt.Sprintf("parse error: %v", err)}
+	}
+
+	var failures []string
+	some_post := func(matches func(Post) bool) bool {
+		return slices.ContainsFunc(posts, matches)
+	}
+	for _, line := range lines {
+		if line == "no-links" {
+			if some_post(func(p Post) bool { return len(p.Links) > 0 }) {
+				failures = append(failures, "expected no links")
+			}
+			continue
+		}
+		key, value, found := strings.Cut(line, ": ")
+		if !found {
+			continue
+		}
+		switch key {
+		case "posts":
+			if strconv.Itoa(len(posts)) != value {
+				failures = append(failures, fmt.Sprintf("posts: got %d, want %v", len(posts), value))
+			}
+		case "post":
+			if !some_post(func(p Post) bool { return p.URL == value }) {
+				failures = append(failures, fmt.Sprintf("missing post: %q", value))
+			}
+		case "author":
+			if !some_post(func(p Post) bool { return p.Author == value }) {
+				failures = append(failures, fmt.Sprintf("missing author: %q", value))
+			}
+		case "link":
+			if !some_post(func(p Post) bool { return slices.Contains(p.Links, value) }) {
+				failures = append(failures, fmt.Sprintf("missing link: %q", value))
+			}
+		case "no-link":
+			if some_post(func(p Post) bool { return slices.Contains(p.Links, value) }) {
+				failures = append(failures, fmt.Sprintf("unexpected link: %q", value))
+			}
+		case "contains":
+			if !some_post(func(p Post) bool { return strings.Contains(p.Text, value) }) {
+				failures = append(failures, fmt.Sprintf("missing text: %q", value))
+			}
+		case "excludes":
+			if some_post(func(p Post) bool { return strings.Contains(p.Text, value) }) {
+				failures = append(failures, fmt.Sprintf("unexpected text: %q", value))
+			}
+		}
+	}
+	return failures
+}
+
+func TestParsers(t *testing.T) {
+	fixtures, err := filepath.Glob("testdata/*.json")
+	if err != nil || len(fixtures) == 0 {
+		t.Fatal("No fixtures found")
+	}
+	for _, fixture := range fixtures {
+		t.Run(filepath.Base(fixture), func(t *testing.T) {
+			for _, failure := range checkFixture(fixture) {
+				t.Error(failure)
+			}
+		})
+	}
+}
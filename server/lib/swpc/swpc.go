@@ -0,0 +1,283 @@
+package swpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+)
+
+/*
+Reads NOAA's Space Weather Prediction Center JSON products, and puts them
+on the NOAA space weather scales, <https://www.swpc.noaa.gov/noaa-scales-explanation>:
+
+	G1-G5  geomagnetic storms, from the planetary K-index
+	R1-R5  radio blackouts, from solar flares' X-ray flux; R3 is an X1 flare
+	S1-S5  solar radiation storms, from proton flux, which we only get as alerts
+*/
+
+const (
+	KpURL     = "https://services.swpc.noaa.gov/products/noaa-planetary-k-index.json"
+	XrayURL   = "https://services.swpc.noaa.gov/json/goes/primary/xrays-6-hour.json"
+	AlertsURL = "https://services.swpc.noaa.gov/products/alerts.json"
+)
+
+// A level on one of the NOAA scales, e.g. G4
+type Level struct {
+	Scale string // "G", "R" or "S"
+	Value int    // 1 to 5, or 0 if below the scale
+}
+
+func (l Level) String() string {
+	return l.Scale + strconv.Itoa(l.Value)
+}
+
+var levelNames = []string{"", "minor", "moderate", "strong", "severe", "extreme"}
+
+func (l Level) Name() string {
+	return levelNames[max(0, min(l.Value, 5))]
+}
+
+var levelPattern = regexp.MustCompile(`^([GRS])([1-5])$`)
+
+func ParseLevel(s string) (Level, error) {
+	match := levelPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(s)))
+	if match == nil {
+		return Level{}, fmt.Errorf("expected a level like G4, R3 or S2, got: %q", s)
+	}
+	value, _ := strconv.Atoi(match[2])
+	return Level{Scale: match[1], Value: value}, nil
+}
+
+// KpToG follows the scale's Kp 5 = G1 ... Kp 9 = G5, rounding Kp values
+// like 7.67, which SWPC writes as 8-
+func KpToG(kp float64) Level {
+	return Level{Scale: "G", Value: max(0, min(int(math.Round(kp))-4, 5))}
+}
+
+// FluxToR takes the 0.1-0.8nm X-ray flux, in W/m²
+func FluxToR(flux float64) Level {
+	value := 0
+	for i, threshold := range []float64{1e-5, 5e-5, 1e-4, 1e-3, 2e-3} {
+		if flux >= threshold {
+			value = i + 1
+		}
+	}
+	return Level{Scale: "R", Value: value}
+}
+
+// FlareClass gives the flux as a flare class, e.g. X1.2
+func FlareClass(flux float64) string {
+	for _, class := range []struct {
+		letter string
+		base   float64
+	}{{"X", 1e-4}, {"M", 1e-5}, {"C", 1e-6}, {"B", 1e-7}} {
+		if flux >= class.base {
+			return fmt.Sprintf("%s%.1f", class.letter, flux/class.base)
+		}
+	}
+	return fmt.Sprintf("A%.1f", flux/1e-8)
+}
+
+type KpReading struct {
+	Time time.Time
+	Kp   float64
+}
+
+// products/*.json timestamps are UTC, without a zone
+const productTimeLayout = "2006-01-02 15:04:05.000"
+
+func parseProductTime(s string) (time.Time, error) {
+	t, err := time.Parse(productTimeLayout, s)
+	if err != nil {
+		t, err = time.Parse(time.RFC3339, s)
+	}
+	return t, err
+}
+
+// ParseKp reads noaa-planetary-k-index.json, which is a table whose first
+// row has the column names
+func ParseKp(body []byte) ([]KpReading, error) {
+	var rows [][]string
+	err := json.Unmarshal(body, &rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("empty K-index table")
+	}
+	time_column, kp_column := -1, -1
+	for i, name := range rows[0] {
+		switch name {
+		case "time_tag":
+			time_column = i
+		case "Kp":
+			kp_column = i
+		}
+	}
+	if time_column < 0 || kp_column < 0 {
+		return nil, fmt.Errorf("unexpected K-index columns: %v", rows[0])
+	}
+	var readings []KpReading
+	for _, row := range rows[1:] {
+		if len(row) <= max(time_column, kp_column) {
+			continue
+		}
+		t, err := parseProductTime(row[time_column])
+		if err != nil {
+			continue
+		}
+		kp, err := strconv.ParseFloat(row[kp_column], 64)
+		if err != nil {
+			continue
+		}
+		readings = append(readings, KpReading{Time: t, Kp: kp})
+	}
+	return readings, nil
+}
+
+type XrayReading struct {
+	Time time.Time
+	Flux float64
+}
+
+// ParseXrays reads the GOES X-ray flux, keeping the 0.1-0.8nm band that
+// flares are classified by
+func ParseXrays(body []byte) ([]XrayReading, error) {
+	var rows []struct {
+		TimeTag string   `json:"time_tag"`
+		Flux    *float64 `json:"flux"`
+		Energy  string   `json:"energy"`
+	}
+	err := json.Unmarshal(body, &rows)
+	if err != nil {
+		return nil, err
+	}
+	var readings []XrayReading
+	for _, row := range rows {
+		if row.Energy != "0.1-0.8nm" || row.Flux == nil {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, row.TimeTag)
+		if err != nil {
+			continue
+		}
+		readings = append(readings, XrayReading{Time: t, Flux: *row.Flux})
+	}
+	return readings, nil
+}
+
+// An alert, watch, warning or summary, as SWPC words it
+type Alert struct {
+	ProductID string
+	Issued    time.Time
+	Message   string
+}
+
+// Headline is the ALERT:, WATCH:, WARNING: ... line
+func (a Alert) Headline() string {
+	for _, line := range strings.Split(a.Message, "\n") {
+		line = strings.TrimSpace(line)
+		for _, prefix := range []string{"ALERT:", "CONTINUED ALERT:", "WATCH:", "WARNING:", "EXTENDED WARNING:", "SUMMARY:", "CANCEL"} {
+			if strings.HasPrefix(line, prefix) {
+				return line
+			}
+		}
+	}
+	return a.ProductID
+}
+
+// IsObserved is true for alerts and summaries, which report levels that
+// have been reached, rather than forecast
+func (a Alert) IsObserved() bool {
+	headline := a.Headline()
+	return strings.HasPrefix(headline, "ALERT:") || strings.HasPrefix(headline, "CONTINUED ALERT:") || strings.HasPrefix(headline, "SUMMARY:")
+}
+
+var (
+	categoryPattern = regexp.MustCompile(`Category ([GRS][1-5])`)
+	kIndexPattern   = regexp.MustCompile(`K-index of ([0-9])`)
+	xrayPattern     = regexp.MustCompile(`X-ray Event exceeded ([MX][0-9.]+)`)
+)
+
+// Level is the highest scale level the alert mentions, if any. Watches
+// and warnings are for levels that haven't been reached yet.
+func (a Alert) Level() (Level, bool) {
+	if match := categoryPattern.FindStringSubmatch(a.Message); match != nil {
+		level, err := ParseLevel(match[1])
+		return level, err == nil
+	}
+	if match := kIndexPattern.FindStringSubmatch(a.Message); match != nil {
+		kp, _ := strconv.ParseFloat(match[1], 64)
+		return KpToG(kp), true
+	}
+	if match := xrayPattern.FindStringSubmatch(a.Message); match != nil {
+		base := 1e-5
+		if match[1][0] == 'X' {
+			base = 1e-4
+		}
+		multiple, err := strconv.ParseFloat(match[1][1:], 64)
+		return FluxToR(base * multiple), err == nil
+	}
+	return Level{}, false
+}
+
+// Scale guesses which scale an alert without a level is about
+func (a Alert) Scale() string {
+	switch {
+	case strings.Contains(a.Message, "Geomagnetic"), strings.Contains(a.Message, "CME"):
+		return "G"
+	case strings.Contains(a.Message, "Proton"), strings.Contains(a.Message, "Radiation Storm"):
+		return "S"
+	case strings.Contains(a.Message, "X-ray"), strings.Contains(a.Message, "Radio"):
+		return "R"
+	}
+	return ""
+}
+
+func ParseAlerts(body []byte) ([]Alert, error) {
+	var rows []struct {
+		ProductID     string `json:"product_id"`
+		IssueDatetime string `json:"issue_datetime"`
+		Message       string `json:"message"`
+	}
+	err := json.Unmarshal(body, &rows)
+	if err != nil {
+		return nil, err
+	}
+	var alerts []Alert
+	for _, row := range rows {
+		issued, err := parseProductTime(row.IssueDatetime)
+		if err != nil {
+			continue
+		}
+		message := strings.ReplaceAll(row.Message, "\r\n", "\n")
+		alerts = append(alerts, Alert{ProductID: row.ProductID, Issued: issued, Message: message})
+	}
+	return alerts, nil
+}
+
+func get[T any](url string, parse func([]byte) (T, error)) (T, error) {
+	var zero T
+	body, err := web.Get(url)
+	if err != nil {
+		return zero, err
+	}
+	parsed, err := parse(body)
+	if err != nil {
+		log.Printf("Error parsing %v: %v", url, err)
+		return zero, err
+	}
+	return parsed, nil
+}
+
+func FetchKp() ([]KpReading, error)      { return get(KpURL, ParseKp) }
+func FetchXrays() ([]XrayReading, error) { return get(XrayURL, ParseXrays) }
+func FetchAlerts() ([]Alert, error)      { return get(AlertsURL, ParseAlerts) }
@@ -0,0 +1,134 @@
+package telegram
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+	"github.com/PuerkitoBio/goquery"
+)
+
+/*
+Public Telegram channels have a web preview at t.me/s/<channel>, which
+shows their latest ~20 messages, oldest first, and older ones with
+?before=<message id>. It needs no account or API key, but only works for
+channels that haven't disabled it.
+*/
+
+type Message struct {
+	Channel string
+	ID      int
+	Text    string
+	Date    time.Time
+	Links   []string // to other sites, from the text
+}
+
+func (m Message) URL() string {
+	return fmt.Sprintf("https://t.me/%s/%d", m.Channel, m.ID)
+}
+
+func PreviewURL(channel string, before int) string {
+	url := "https://t.me/s/" + channel
+	if before > 0 {
+		url += "?before=" + strconv.Itoa(before)
+	}
+	return url
+}
+
+// ParseChannel reads the messages in a t.me/s page. Messages without text,
+// e.g. a photo without a caption, are skipped, but still count towards
+// oldest, the id of the oldest message on the page, which is 0 if it has
+// none.
+func ParseChannel(page []byte, channel string) (messages []Message, oldest int, err error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		return nil, 0, err
+	}
+	if doc.Find(".tgme_channel_info").Length() == 0 && doc.Find(".tgme_widget_message").Length() == 0 {
+		return nil, 0, errors.New("not a channel preview: " + channel + " may be private or have previews disabled")
+	}
+
+	doc.Find(".tgme_widget_message[data-post]").Each(func(_ int, s *goquery.Selection) {
+		// data-post is "<channel>/<id>"
+		data_post, _ := s.Attr("data-post")
+		id, err := strconv.Atoi(data_post[strings.LastIndex(data_post, "/")+1:])
+		if err != nil {
+			return
+		}
+		if oldest == 0 || id < oldest {
+			oldest = id
+		}
+		text_selection := s.Find(".tgme_widget_message_text").First()
+		text_selection.Find("br").ReplaceWithHtml("\n")
+		text := strings.TrimSpace(text_selection.Text())
+		if text == "" {
+			return
+		}
+		message := Message{Channel: channel, ID: id, Text: text}
+
+		datetime, _ := s.Find(".tgme_widget_message_date time").Attr("datetime")
+		message.Date, err = time.Parse(time.RFC3339, datetime)
+		if err != nil {
+			log.Printf("Error parsing message date %q: %v", datetime, err)
+			message.Date = time.Now()
+		}
+
+		text_selection.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+			href, _ := a.Attr("href")
+			if !strings.HasPrefix(href, "http") || strings.HasPrefix(href, "https://t.me/") {
+				return
+			}
+			for _, link := range message.Links {
+				if link == href {
+					return
+				}
+			}
+			message.Links = append(message.Links, href)
+		})
+		messages = append(messages, message)
+	})
+	return messages, oldest, nil
+}
+
+// MessagesAfter returns the channel's messages newer than after_id, oldest
+// first. If there are more than fit in a page, it goes back up to
+// max_pages; if after_id is 0, it just reads the latest page. If an older
+// page can't be read, it fails rather than return messages with a gap
+// before them, which the caller would skip over.
+func MessagesAfter(channel string, after_id int, max_pages int) ([]Message, error) {
+	var messages []Message
+	seen := map[int]bool{}
+	before := 0
+	for page := 0; page < max(max_pages, 1); page++ {
+		body, err := web.Get(PreviewURL(channel, before))
+		if err != nil {
+			return nil, err
+		}
+		page_messages, oldest, err := ParseChannel(body, channel)
+		if err != nil {
+			log.Printf("Error parsing %v: %v", PreviewURL(channel, before), err)
+			return nil, err
+		}
+		if oldest == 0 {
+			break
+		}
+		for _, message := range page_messages {
+			if message.ID > after_id && !seen[message.ID] {
+				seen[message.ID] = true
+				messages = append(messages, message)
+			}
+		}
+		if after_id == 0 || oldest <= after_id+1 || oldest == before {
+			break
+		}
+		before = oldest
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+	return messages, nil
+}
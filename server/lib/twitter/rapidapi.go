@@ -0,0 +1,122 @@
+package twitter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// The twitter-api45 API on RapidAPI, <https://rapidapi.com/alexanderxbx/api/twitter-api45>,
+// paid for with RAPIDAPI_KEY
+type twitterAPI45 struct {
+	key  string
+	host string
+}
+
+func NewTwitterAPI45(key string) Client {
+	return twitterAPI45{key: key, host: "twitter-api45.p.rapidapi.com"}
+}
+
+// created_at, as in the old v1.1 API
+const api45DateLayout = "Mon Jan 02 15:04:05 -0700 2006"
+
+type api45Tweet struct {
+	TweetID    string `json:"tweet_id"`
+	Text       string `json:"text"`
+	CreatedAt  string `json:"created_at"`
+	ScreenName string `json:"screen_name"`
+	Author     struct {
+		ScreenName string `json:"screen_name"`
+	} `json:"author"`
+	UserInfo struct {
+		ScreenName string `json:"screen_name"`
+	} `json:"user_info"`
+}
+
+type api45Response struct {
+	Status   string       `json:"status"`
+	Timeline []api45Tweet `json:"timeline"`
+}
+
+func (c twitterAPI45) get(path string, params url.Values) (api45Response, error) {
+	var response api45Response
+	req, err := http.NewRequest("GET", "https://"+c.host+path+"?"+params.Encode(), nil)
+	if err != nil {
+		log.Printf("Error creating request: %v", err)
+		return response, err
+	}
+	req.Header.Set("x-rapidapi-key", c.key)
+	req.Header.Set("x-rapidapi-host", c.host)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("GET error: %v", err)
+		return response, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("Error reading body: %v", err)
+		return response, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("Status error: %v: %s", resp.StatusCode, body)
+		return response, errors.New("Error: http status not OK")
+	}
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		log.Printf("Error parsing %v response: %v", path, err)
+		return response, err
+	}
+	if response.Status != "" && response.Status != "ok" {
+		return response, fmt.Errorf("%v returned status %q", path, response.Status)
+	}
+	return response, nil
+}
+
+func (c twitterAPI45) toTweets(response api45Response, default_author string) []Tweet {
+	var tweets []Tweet
+	for _, t := range response.Timeline {
+		if t.TweetID == "" {
+			continue // ads, "who to follow", etc.
+		}
+		author := default_author
+		for _, name := range []string{t.Author.ScreenName, t.UserInfo.ScreenName, t.ScreenName} {
+			if name != "" {
+				author = name
+				break
+			}
+		}
+		created_at, err := time.Parse(api45DateLayout, t.CreatedAt)
+		if err != nil {
+			log.Printf("Error parsing tweet date %q: %v", t.CreatedAt, err)
+			created_at = time.Now()
+		}
+		tweets = append(tweets, Tweet{ID: t.TweetID, Text: t.Text, Author: author, CreatedAt: created_at.UTC()})
+	}
+	SortNewestFirst(tweets)
+	return tweets
+}
+
+func (c twitterAPI45) UserTweets(screen_name string) ([]Tweet, error) {
+	screen_name = strings.TrimPrefix(screen_name, "@")
+	response, err := c.get("/timeline.php", url.Values{"screenname": {screen_name}})
+	if err != nil {
+		return nil, err
+	}
+	return c.toTweets(response, screen_name), nil
+}
+
+func (c twitterAPI45) Search(query string) ([]Tweet, error) {
+	response, err := c.get("/search.php", url.Values{"query": {query}, "search_type": {"Latest"}})
+	if err != nil {
+		return nil, err
+	}
+	return c.toTweets(response, ""), nil
+}
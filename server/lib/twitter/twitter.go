@@ -0,0 +1,43 @@
+package twitter
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+/*
+X/Twitter doesn't have a usable free API, so tweets come from third party
+APIs, which come and go. Each one is a Client, registered in Clients under
+the name used in config/tweets.yaml, so that switching providers is a
+config change.
+*/
+
+type Tweet struct {
+	ID        string
+	Text      string
+	Author    string // screen name, without the @
+	CreatedAt time.Time
+}
+
+func (t Tweet) URL() string {
+	return fmt.Sprintf("https://x.com/%s/status/%s", t.Author, t.ID)
+}
+
+type Client interface {
+	// The account's latest tweets, including retweets and replies
+	UserTweets(screen_name string) ([]Tweet, error)
+	// The latest tweets matching an X search query
+	Search(query string) ([]Tweet, error)
+}
+
+var Clients = map[string]func(key string) Client{
+	"twitter-api45": NewTwitterAPI45,
+}
+
+// Timelines put pinned tweets first
+func SortNewestFirst(tweets []Tweet) {
+	sort.SliceStable(tweets, func(i, j int) bool {
+		return tweets[i].CreatedAt.After(tweets[j].CreatedAt)
+	})
+}
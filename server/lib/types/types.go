@@ -27,5 +27,11 @@ type ExpandedSource struct {
 	Summary             string
 	ImportanceBool      bool
 	ImportanceReasoning string
+	ImportancePrompt    string // which of llm.ImportanceChecks it was scored with
 	Origin              string
+	OriginalDate        string // if Date was extracted from the article, what we had before
+	DateMethod          string // where Date came from, see lib/pubdate
+	SnapshotID          int64  // the page_snapshots row it was scored on, if any
+	Tags                []string
+	NumReportingSources int // how widely the story had been reported, if known
 }
@@ -0,0 +1,137 @@
+package usgs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+/*
+Parses the USGS earthquake GeoJSON summary feeds,
+<https://earthquake.usgs.gov/earthquakes/feed/v1.0/geojson.php>. USGS
+revises events as more data comes in, e.g. the magnitude, or the PAGER
+alert once casualty estimates are made, and bumps Updated when it does.
+*/
+
+// PAGER alert levels, by estimated fatalities and economic losses
+var AlertLevels = []string{"green", "yellow", "orange", "red"}
+
+// AlertRank is 0 for no alert, and 1 to 4 for green to red
+func AlertRank(alert string) int {
+	for i, level := range AlertLevels {
+		if level == alert {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+type Earthquake struct {
+	ID        string   // the preferred id, which may change between revisions
+	IDs       []string // every id the event has had, from each network
+	Magnitude float64
+	Place     string
+	Time      time.Time
+	Updated   time.Time
+	URL       string
+	Alert     string // "", or a PAGER alert level
+	Tsunami   bool   // whether NOAA's tsunami centers have a product for it
+	Status    string // "automatic" or "reviewed"
+	Latitude  float64
+	Longitude float64
+	DepthKm   float64
+}
+
+func (e Earthquake) Title() string {
+	title := fmt.Sprintf("M%.1f earthquake, %s", e.Magnitude, e.Place)
+	var flags []string
+	if e.Alert != "" {
+		flags = append(flags, "PAGER "+e.Alert)
+	}
+	if e.Tsunami {
+		flags = append(flags, "tsunami")
+	}
+	if len(flags) > 0 {
+		title += " (" + strings.Join(flags, ", ") + ")"
+	}
+	return title
+}
+
+func (e Earthquake) Summary() string {
+	alert := e.Alert
+	if alert == "" {
+		alert = "none yet"
+	}
+	return fmt.Sprintf("Magnitude: %.1f\nLocation: %s (%.3f, %.3f), %.0f km deep\nTime: %s\nPAGER alert: %s\nTsunami: %t\nStatus: %s, last updated %s",
+		e.Magnitude, e.Place, e.Latitude, e.Longitude, e.DepthKm, e.Time.UTC().Format(time.RFC3339), alert, e.Tsunami, e.Status, e.Updated.UTC().Format(time.RFC3339))
+}
+
+type featureCollection struct {
+	Features []struct {
+		ID         string `json:"id"`
+		Properties struct {
+			Mag     *float64 `json:"mag"`
+			Place   string   `json:"place"`
+			Time    int64    `json:"time"`
+			Updated int64    `json:"updated"`
+			URL     string   `json:"url"`
+			Alert   *string  `json:"alert"`
+			Tsunami int      `json:"tsunami"`
+			Status  string   `json:"status"`
+			Type    string   `json:"type"`
+			IDs     string   `json:"ids"`
+			Title   string   `json:"title"`
+		} `json:"properties"`
+		Geometry struct {
+			Coordinates []float64 `json:"coordinates"` // longitude, latitude, depth
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+// Parse reads a summary feed. Quarry blasts and other non-earthquakes, and
+// events without a magnitude yet, are skipped.
+func Parse(body []byte) ([]Earthquake, error) {
+	var collection featureCollection
+	err := json.Unmarshal(body, &collection)
+	if err != nil {
+		return nil, err
+	}
+	var earthquakes []Earthquake
+	for _, feature := range collection.Features {
+		p := feature.Properties
+		if p.Type != "earthquake" || p.Mag == nil {
+			continue
+		}
+		earthquake := Earthquake{
+			ID:        feature.ID,
+			Magnitude: *p.Mag,
+			Place:     p.Place,
+			Time:      time.UnixMilli(p.Time).UTC(),
+			Updated:   time.UnixMilli(p.Updated).UTC(),
+			URL:       p.URL,
+			Tsunami:   p.Tsunami == 1,
+			Status:    p.Status,
+		}
+		if earthquake.Place == "" {
+			earthquake.Place = strings.TrimPrefix(p.Title, fmt.Sprintf("M %.1f - ", *p.Mag))
+		}
+		if p.Alert != nil {
+			earthquake.Alert = *p.Alert
+		}
+		// ids is like ",us7000abcd,at00xyz,"
+		for _, id := range strings.Split(p.IDs, ",") {
+			if id != "" {
+				earthquake.IDs = append(earthquake.IDs, id)
+			}
+		}
+		if len(earthquake.IDs) == 0 {
+			earthquake.IDs = []string{feature.ID}
+		}
+		if coordinates := feature.Geometry.Coordinates; len(coordinates) >= 3 {
+			earthquake.Longitude, earthquake.Latitude, earthquake.DepthKm = coordinates[0], coordinates[1], coordinates[2]
+		}
+		earthquakes = append(earthquakes, earthquake)
+	}
+	return earthquakes, nil
+}
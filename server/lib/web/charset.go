@@ -0,0 +1,220 @@
+package web
+
+import (
+	"bytes"
+	"log"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+)
+
+/*
+Many pages, e.g. from mil.gmw.cn or regional outlets, are served as
+GB2312/GBK, Big5, Shift-JIS or Windows-1251 rather than UTF-8. Our html
+tokenizers assume UTF-8, so we transcode before parsing anything. We look,
+in order, at:
+
+1. Byte order marks and the Content-Type header
+2. <meta charset> declarations, anywhere in the <head>
+3. Whether the bytes are valid UTF-8
+4. Which legacy encoding makes the bytes look most like natural text
+*/
+
+var metaCharset = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([a-zA-Z0-9_:.-]+)`)
+
+// Characters which make up a large share of running text in each
+// encoding's language, but rarely come out of decoding something else
+var sniffCandidates = []struct {
+	label  string
+	common func(r rune) bool
+}{
+	{"gbk", inSet("的一是不了在人有我他这中大来上国个到说们为子和你地出道也时年得就那要下以生会自着去之过家学对可她里后小么心多天而能好都然没日于起还发成事只作当想看文无开手十用主行方又如前所本见经头面公同三已老从动两长知民样现分将外但身些与高意进把法此实回二理美点月明其种声全工己话儿者向情部正名定女问力机给等几很业最")},
+	{"big5", inSet("的一是不了在人有我他這中大來上國個到說們為子和你地出道也時年得就那要下以生會自著去之過家學對可她裡後小麼心多天而能好都然沒日於起還發成事只作當想看文無開手十用主行方又如前所本見經頭面公同三已老從動兩長知民樣現分將外但身些與高意進把法此實回二理美點月明其種聲全工己話兒者向情部正名定女問力機給等幾很業最")},
+	{"shift_jis", func(r rune) bool {
+		// Half-width katakana is what other encodings' bytes tend to turn into
+		is_full_width_katakana := unicode.Is(unicode.Katakana, r) && !isHalfWidthKatakana(r)
+		return unicode.Is(unicode.Hiragana, r) || is_full_width_katakana || r == '。' || r == '、'
+	}},
+	{"euc-kr", inSet("이다는의에하고을가지기서한로도사대리수인자정일어전시들국보적부니해게요있으것제를은과나그만년방경주장공성상동위원")},
+	{"windows-1251", inSet("оеаинтсрвлкмдпу")},
+}
+
+func isHalfWidthKatakana(r rune) bool {
+	return r >= 0xFF61 && r <= 0xFF9F
+}
+
+func inSet(chars string) func(r rune) bool {
+	set := map[rune]bool{}
+	for _, r := range chars {
+		set[r] = true
+	}
+	return func(r rune) bool { return set[r] }
+}
+
+// sniffEncoding decodes the body with each candidate encoding, and picks
+// the one whose non-ascii characters are most often common ones
+func sniffEncoding(body []byte) (encoding.Encoding, string) {
+	best_score := 0.0
+	var best_encoding encoding.Encoding
+	best_name := ""
+	for _, candidate := range sniffCandidates {
+		e, name := charset.Lookup(candidate.label)
+		if e == nil {
+			continue
+		}
+		decoded, err := e.NewDecoder().Bytes(body)
+		if err != nil {
+			continue
+		}
+		// Weigh each character by how many bytes it took to encode,
+		// so that single- and double-byte encodings are comparable
+		non_ascii, common, invalid := 0, 0, 0
+		for _, r := range string(decoded) {
+			if r < utf8.RuneSelf {
+				continue
+			}
+			width := 1
+			if r > 0x2000 && !isHalfWidthKatakana(r) {
+				width = 2
+			}
+			non_ascii += width
+			if r == utf8.RuneError {
+				invalid += width
+			} else if candidate.common(r) {
+				common += width
+			}
+		}
+		if non_ascii == 0 {
+			continue
+		}
+		score := float64(common-5*invalid) / float64(non_ascii)
+		if score > best_score {
+			best_score, best_encoding, best_name = score, e, name
+		}
+	}
+	return best_encoding, best_name
+}
+
+// DetermineEncoding works out which encoding a page is in, and whether
+// that was declared or guessed
+func DetermineEncoding(body []byte, content_type string) (encoding.Encoding, string, bool) {
+	e, name, certain := charset.DetermineEncoding(body, content_type)
+	if certain {
+		if name != "utf-8" || utf8.Valid(body) {
+			return e, name, true
+		}
+		// Declared as utf-8, but isn't; fall through to sniffing
+	} else if matches := metaCharset.FindSubmatch(headOf(body)); matches != nil {
+		// charset.DetermineEncoding only looks at the first 1024 bytes,
+		// but <meta charset> often comes after a pile of scripts
+		if declared, declared_name := charset.Lookup(string(matches[1])); declared != nil {
+			if declared_name != "utf-8" || utf8.Valid(body) {
+				return declared, declared_name, true
+			}
+		}
+	}
+	if utf8.Valid(body) {
+		utf8_encoding, _ := charset.Lookup("utf-8")
+		return utf8_encoding, "utf-8", false
+	}
+	if sniffed, sniffed_name := sniffEncoding(body); sniffed != nil {
+		return sniffed, sniffed_name, false
+	}
+	return e, name, false
+}
+
+func headOf(body []byte) []byte {
+	if end := bytes.Index(bytes.ToLower(body), []byte("</head>")); end != -1 {
+		return body[:end]
+	}
+	if len(body) > 8192 {
+		return body[:8192]
+	}
+	return body
+}
+
+// ToUTF8 transcodes an html page to UTF-8
+func ToUTF8(body []byte, content_type string) ([]byte, string, error) {
+	e, name, _ := DetermineEncoding(body, content_type)
+	if name == "utf-8" {
+		return body, name, nil
+	}
+	decoded, err := e.NewDecoder().Bytes(body)
+	if err != nil {
+		log.Printf("Error transcoding page from %v: %v", name, err)
+		return body, name, err
+	}
+	return decoded, name, nil
+}
+
+var xmlDeclaration = regexp.MustCompile(`^(\s*<\?xml[^>]*encoding\s*=\s*["'])([a-zA-Z0-9_:.-]+)(["'])`)
+
+// XMLToUTF8 transcodes a feed to UTF-8, going by the charset in the
+// Content-Type header or, failing that, the <?xml?> declaration. The
+// declaration is rewritten to say utf-8, since encoding/xml won't parse
+// anything that claims to be in another encoding.
+func XMLToUTF8(body []byte, content_type string) ([]byte, string, error) {
+	label := ""
+	if matches := xmlDeclaration.FindSubmatch(body); matches != nil {
+		label = string(matches[2])
+	}
+	if _, params, err := mime.ParseMediaType(content_type); err == nil && params["charset"] != "" {
+		label = params["charset"]
+	}
+
+	var e encoding.Encoding
+	name := ""
+	if label != "" {
+		e, name = charset.Lookup(label)
+	}
+	if e == nil || (name == "utf-8" && !utf8.Valid(body)) {
+		// Undeclared or wrongly declared
+		if utf8.Valid(body) {
+			return body, "utf-8", nil
+		}
+		e, name = sniffEncoding(body)
+		if e == nil {
+			return body, "", nil
+		}
+	}
+	if name != "utf-8" {
+		decoded, err := e.NewDecoder().Bytes(body)
+		if err != nil {
+			log.Printf("Error transcoding feed from %v: %v", name, err)
+			return body, name, err
+		}
+		body = decoded
+	}
+	return xmlDeclaration.ReplaceAll(body, []byte("${1}utf-8${3}")), name, nil
+}
+
+// transcode brings html pages and xml feeds to UTF-8, and leaves anything
+// else, e.g. json, as it is
+func transcode(body []byte, content_type string) []byte {
+	if isHTML(content_type, body) {
+		body, _, _ = ToUTF8(body, content_type)
+	} else if isXML(content_type, body) {
+		body, _, _ = XMLToUTF8(body, content_type)
+	}
+	return body
+}
+
+func isXML(content_type string, body []byte) bool {
+	if strings.Contains(strings.ToLower(content_type), "xml") {
+		return true
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(body[:min(len(body), 512)]), []byte("<?xml"))
+}
+
+func isHTML(content_type string, body []byte) bool {
+	if content_type != "" {
+		return strings.Contains(strings.ToLower(content_type), "html")
+	}
+	start := strings.ToLower(string(body[:min(len(body), 512)]))
+	return strings.Contains(start, "<html") || strings.Contains(start, "<!doctype html")
+}
@@ -0,0 +1,118 @@
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/*
+Transcodes the pages in lib/web/testdata, which are saved in GBK, Big5,
+Shift-JIS and Windows-1251, some with a declared charset and some without,
+and checks each one against its .expected file:
+
+	content-type: the Content-Type header the page would have been served with
+	charset: the encoding we should detect
+	title: the page's <title>, once transcoded to UTF-8
+
+The .xml files there are feeds, whose title line is their first item's.
+
+Usage: make check-charsets, or go test ./lib/web
+*/
+
+func readExpected(path string) (map[string]string, error) {
+	expected := map[string]string{}
+	f, err := os.Open(path)
+	if err != nil {
+		return expected, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ": ")
+		if found {
+			expected[key] = value
+		}
+	}
+	return expected, scanner.Err()
+}
+
+// feedTitle is the title of the feed's first item. encoding/xml refuses
+// documents which declare an encoding other than UTF-8, so this also
+// checks that XMLToUTF8 rewrote the declaration.
+func feedTitle(feed []byte) (string, error) {
+	var rss struct {
+		Items []struct {
+			Title string `xml:"title"`
+		} `xml:"channel>item"`
+	}
+	err := xml.Unmarshal(feed, &rss)
+	if err != nil {
+		return "", err
+	}
+	if len(rss.Items) == 0 {
+		return "", fmt.Errorf("no items")
+	}
+	return rss.Items[0].Title, nil
+}
+
+func checkFixture(path string) []string {
+	page, err := os.ReadFile(path)
+	if err != nil {
+		return []string{fmt.Sprintf("reading fixture: %v", err)}
+	}
+	expected, err := readExpected(strings.TrimSuffix(path, filepath.Ext(path)) + ".expected")
+	if err != nil {
+		return []string{fmt.Sprintf("reading expectations: %v", err)}
+	}
+
+	var failures []string
+	is_feed := filepath.Ext(path) == ".xml"
+	var transcoded []byte
+	var name string
+	if is_feed {
+		transcoded, name, err = XMLToUTF8(page, expected["content-type"])
+	} else {
+		transcoded, name, err = ToUTF8(page, expected["content-type"])
+	}
+	if err != nil {
+		failures = append(failures, fmt.Sprintf("transcoding error: %v", err))
+	}
+	if name != expected["charset"] {
+		failures = append(failures, fmt.Sprintf("charset: got %q, want %q", name, expected["charset"]))
+	}
+	var title string
+	if is_feed {
+		title, err = feedTitle(transcoded)
+	} else {
+		title, err = GetTitle(bytes.NewReader(transcoded))
+	}
+	if err != nil {
+		failures = append(failures, fmt.Sprintf("title error: %v", err))
+	}
+	if title != expected["title"] {
+		failures = append(failures, fmt.Sprintf("title: got %q, want %q", title, expected["title"]))
+	}
+	return failures
+}
+
+func TestCharsets(t *testing.T) {
+	pages, _ := filepath.Glob("testdata/*.html")
+	feed_fixtures, _ := filepath.Glob("testdata/*.xml")
+	fixtures := append(pages, feed_fixtures...)
+	if len(fixtures) == 0 {
+		t.Fatal("No fixtures found")
+	}
+	for _, fixture := range fixtures {
+		t.Run(filepath.Base(fixture), func(t *testing.T) {
+			for _, failure := range checkFixture(fixture) {
+				t.Error(failure)
+			}
+		})
+	}
+}
@@ -32,7 +32,58 @@ func Get(url string) ([]byte, error) {
 		log.Printf("Error reading body: %v", err)
 		return nil, err
 	}
-	return body, nil
+
+	// Pages and feeds in GBK, Big5, Windows-1251, etc. are transcoded to
+	// UTF-8 before anything tries to parse them
+	return transcode(body, resp.Header.Get("Content-Type")), nil
+}
+
+type ConditionalResponse struct {
+	Body         []byte
+	ETag         string
+	LastModified string
+	NotModified  bool
+}
+
+// like Get, but sends If-None-Match/If-Modified-Since, so that
+// unchanged feeds come back as a bodyless 304
+func GetConditional(url string, etag string, last_modified string) (ConditionalResponse, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		log.Printf("Error creating request: %v", err)
+		return ConditionalResponse{}, err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if last_modified != "" {
+		req.Header.Set("If-Modified-Since", last_modified)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("GET error: %v", err)
+		return ConditionalResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return ConditionalResponse{ETag: etag, LastModified: last_modified, NotModified: true}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("Status error: %v", resp.StatusCode)
+		return ConditionalResponse{}, errors.New("Error: http status not OK")
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("Error reading body: %v", err)
+		return ConditionalResponse{}, err
+	}
+	return ConditionalResponse{
+		Body:         transcode(body, resp.Header.Get("Content-Type")),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
 }
 
 /* Html cleanup functions */
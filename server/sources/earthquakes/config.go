@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/usgs"
+	"gopkg.in/yaml.v3"
+)
+
+// See config/earthquakes.yaml
+type EarthquakesConfig struct {
+	Feed       string `yaml:"feed"`
+	Cadence    string `yaml:"cadence"`
+	Thresholds struct {
+		Magnitude        float64 `yaml:"magnitude"`
+		Alert            string  `yaml:"alert"`
+		TsunamiMagnitude float64 `yaml:"tsunami_magnitude"`
+	} `yaml:"thresholds"`
+	Tags []string `yaml:"tags"`
+
+	cadence time.Duration
+}
+
+// LoadConfig reads config/earthquakes.yaml, fills in defaults, and refuses
+// to start on a config we would only notice was broken hours later
+func LoadConfig(path string) (EarthquakesConfig, error) {
+	config := EarthquakesConfig{Cadence: "5m"}
+	config.Thresholds.Magnitude = 6.5
+	config.Thresholds.Alert = "yellow"
+	config.Thresholds.TsunamiMagnitude = 6.0
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return config, fmt.Errorf("Error parsing %v: %v", path, err)
+	}
+	if config.Feed == "" {
+		return config, fmt.Errorf("No feed in %v", path)
+	}
+	config.cadence, err = time.ParseDuration(config.Cadence)
+	if err != nil || config.cadence < time.Minute {
+		return config, fmt.Errorf("Invalid cadence: %q", config.Cadence)
+	}
+	if usgs.AlertRank(config.Thresholds.Alert) == 0 {
+		return config, fmt.Errorf("Invalid alert threshold: %q, should be one of %v", config.Thresholds.Alert, usgs.AlertLevels)
+	}
+	if config.Thresholds.Magnitude <= 0 || config.Thresholds.TsunamiMagnitude <= 0 {
+		return config, fmt.Errorf("Magnitude thresholds should be positive")
+	}
+	return config, nil
+}
+
+// Crosses says which thresholds an earthquake crosses, if any
+func (c EarthquakesConfig) Crosses(earthquake usgs.Earthquake) []string {
+	var reasons []string
+	if earthquake.Magnitude >= c.Thresholds.Magnitude {
+		reasons = append(reasons, fmt.Sprintf("magnitude %.1f is at least %.1f", earthquake.Magnitude, c.Thresholds.Magnitude))
+	}
+	if usgs.AlertRank(earthquake.Alert) >= usgs.AlertRank(c.Thresholds.Alert) {
+		reasons = append(reasons, fmt.Sprintf("PAGER alert %v is at least %v", earthquake.Alert, c.Thresholds.Alert))
+	}
+	if earthquake.Tsunami && earthquake.Magnitude >= c.Thresholds.TsunamiMagnitude {
+		reasons = append(reasons, fmt.Sprintf("tsunami flag, with magnitude %.1f", earthquake.Magnitude))
+	}
+	return reasons
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/feedstate"
+	"git.nunosempere.com/NunoSempere/news/lib/usgs"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+
+	logFile, err := os.OpenFile("sources/earthquakes/v2.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		log.Fatalf("error opening file: %v", err)
+	}
+	defer logFile.Close()
+	mw := io.MultiWriter(os.Stdout, logFile)
+	log.SetOutput(mw)
+
+	// Get keys. No OpenAI key: items come from thresholds, not an LLM
+	err = godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	pg_database_url := os.Getenv("DATABASE_POOL_URL")
+
+	config, err := LoadConfig("config/earthquakes.yaml")
+	if err != nil {
+		log.Fatalf("Error loading earthquakes config: %v", err)
+	}
+
+	ticker := time.NewTicker(config.cadence)
+	defer ticker.Stop()
+	for ; true; <-ticker.C {
+		// Every earthquake in the feed is looked at each time, since any of
+		// them may have been revised
+		body, state, unchanged, err := feedstate.Fetch(config.Feed, pg_database_url)
+		if err != nil || unchanged {
+			continue
+		}
+		earthquakes, err := usgs.Parse(body)
+		if err != nil {
+			log.Printf("Error parsing %v: %v", config.Feed, err)
+			continue
+		}
+		// The state is only saved once every earthquake is, so that a
+		// failed one is looked at again, even if the feed hasn't changed
+		failed := false
+		for _, earthquake := range earthquakes {
+			if SaveEarthquake(earthquake, config) != nil {
+				failed = true
+			}
+		}
+		if !failed {
+			state.NumItems = len(earthquakes)
+			feedstate.Save(state, pg_database_url)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/pipeline"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+	"git.nunosempere.com/NunoSempere/news/lib/usgs"
+	"github.com/jackc/pgx/v5"
+)
+
+// SaveEarthquake saves an earthquake that crosses a threshold, or, if we
+// already have it, brings it up to date with USGS' latest revision. The
+// earthquake and its item in sources are saved together, or not at all.
+func SaveEarthquake(earthquake usgs.Earthquake, config EarthquakesConfig) error {
+	return pipeline.InTransaction(os.Getenv("DATABASE_POOL_URL"), func(tx pgx.Tx) error {
+		return saveEarthquake(tx, earthquake, config)
+	})
+}
+
+func saveEarthquake(tx pgx.Tx, earthquake usgs.Earthquake, config EarthquakesConfig) error {
+	ctx := context.Background()
+	var id int
+	var usgs_ids []string
+	var link, alert string
+	var tsunami bool
+	var usgs_updated_at time.Time
+	err := tx.QueryRow(ctx, `
+        SELECT id, usgs_ids, link, alert, tsunami, usgs_updated_at FROM earthquakes WHERE usgs_ids && $1
+    `, earthquake.IDs).Scan(&id, &usgs_ids, &link, &alert, &tsunami, &usgs_updated_at)
+
+	if err == pgx.ErrNoRows {
+		reasons := config.Crosses(earthquake)
+		if len(reasons) == 0 {
+			return nil
+		}
+		_, err = tx.Exec(ctx, `
+            INSERT INTO earthquakes (usgs_ids, link, magnitude, place, latitude, longitude, depth_km, alert, tsunami, status, occurred_at, usgs_updated_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+        `, earthquake.IDs, earthquake.URL, earthquake.Magnitude, earthquake.Place, earthquake.Latitude, earthquake.Longitude, earthquake.DepthKm, earthquake.Alert, earthquake.Tsunami, earthquake.Status, earthquake.Time, earthquake.Updated)
+		if err != nil {
+			log.Printf("Error saving earthquake: %v\n", err)
+			return err
+		}
+		err = pipeline.InsertSource(tx, types.ExpandedSource{
+			Title:               earthquake.Title(),
+			Link:                earthquake.URL,
+			Date:                earthquake.Time.Format(time.RFC3339),
+			Summary:             earthquake.Summary(),
+			ImportanceBool:      true,
+			ImportanceReasoning: "Crossed thresholds in config/earthquakes.yaml: " + strings.Join(reasons, "; "),
+			DateMethod:          "usgs",
+			Tags:                config.Tags,
+		})
+		if err != nil && err != pipeline.ErrAlreadySaved {
+			return err
+		}
+		return nil
+	}
+	if err != nil {
+		log.Printf("Error loading earthquake: %v\n", err)
+		return err
+	}
+
+	if !earthquake.Updated.After(usgs_updated_at) {
+		return nil
+	}
+	for _, usgs_id := range earthquake.IDs {
+		if !slices.Contains(usgs_ids, usgs_id) {
+			usgs_ids = append(usgs_ids, usgs_id)
+		}
+	}
+	worse := usgs.AlertRank(earthquake.Alert) > usgs.AlertRank(alert) || (earthquake.Tsunami && !tsunami)
+	_, err = tx.Exec(ctx, `
+        UPDATE earthquakes SET usgs_ids = $1, magnitude = $2, place = $3, latitude = $4, longitude = $5, depth_km = $6,
+            alert = $7, tsunami = $8, status = $9, occurred_at = $10, usgs_updated_at = $11
+        WHERE id = $12
+    `, usgs_ids, earthquake.Magnitude, earthquake.Place, earthquake.Latitude, earthquake.Longitude, earthquake.DepthKm, earthquake.Alert, earthquake.Tsunami, earthquake.Status, earthquake.Time, earthquake.Updated, id)
+	if err != nil {
+		log.Printf("Error updating earthquake: %v\n", err)
+		return err
+	}
+	err = pipeline.UpdateSource(tx, pipeline.Revision{
+		Link:      link,
+		Title:     earthquake.Title(),
+		Summary:   earthquake.Summary(),
+		Worse:     worse,
+		Reasoning: "Revised by USGS: " + earthquake.Title(),
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Updated earthquake: %v\n", earthquake.Title())
+	return nil
+}
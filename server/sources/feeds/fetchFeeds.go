@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/feeds"
+	"git.nunosempere.com/NunoSempere/news/lib/feedstate"
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"gopkg.in/yaml.v3"
+)
+
+type FeedConfig struct {
+	Name      string   `yaml:"name"`
+	Url       string   `yaml:"url"`
+	Cadence   string   `yaml:"cadence"`
+	Prompt    string   `yaml:"prompt"`
+	FreshDays int      `yaml:"fresh_days"`
+	Tags      []string `yaml:"tags"`
+
+	cadence time.Duration
+}
+
+type FeedsConfig struct {
+	Feeds []FeedConfig `yaml:"feeds"`
+}
+
+// An item from a feed, with the settings of the feed it came from
+type FeedItem struct {
+	feeds.Item
+	Feed FeedConfig
+}
+
+// LoadConfig reads config/feeds.yaml, fills in defaults, and refuses to
+// start on a config we would only notice was broken hours later
+func LoadConfig(path string) (FeedsConfig, error) {
+	var config FeedsConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return config, fmt.Errorf("Error parsing %v: %v", path, err)
+	}
+	if len(config.Feeds) == 0 {
+		return config, errors.New("No feeds in " + path)
+	}
+	for i := range config.Feeds {
+		feed := &config.Feeds[i]
+		if feed.Name == "" || feed.Url == "" {
+			return config, fmt.Errorf("Feed #%d needs a name and a url", i+1)
+		}
+		if feed.Cadence == "" {
+			feed.Cadence = "1h"
+		}
+		feed.cadence, err = time.ParseDuration(feed.Cadence)
+		if err != nil || feed.cadence < time.Minute {
+			return config, fmt.Errorf("Feed %q has an invalid cadence: %q", feed.Name, feed.Cadence)
+		}
+		if feed.Prompt == "" {
+			feed.Prompt = "default"
+		}
+		if _, exists := llm.ImportanceChecks[feed.Prompt]; !exists {
+			return config, fmt.Errorf("Feed %q has an unknown prompt: %q", feed.Name, feed.Prompt)
+		}
+		if feed.FreshDays == 0 {
+			feed.FreshDays = 15
+		}
+	}
+	return config, nil
+}
+
+// FetchFeed returns the items we haven't processed in a previous poll. The
+// caller saves the feed state once it has, see feedstate.Advance.
+func FetchFeed(feed FeedConfig, database_url string) ([]FeedItem, feedstate.FeedState, error) {
+	body, state, unchanged, err := feedstate.Fetch(feed.Url, database_url)
+	if err != nil {
+		return nil, state, err
+	}
+	if unchanged {
+		return nil, state, nil
+	}
+
+	items, err := feeds.Parse(body)
+	if err != nil {
+		log.Printf("Error parsing feed %v: %v", feed.Url, err)
+		return nil, state, err
+	}
+
+	var item_ids []string
+	for _, item := range items {
+		item_ids = append(item_ids, item.ID)
+	}
+	state, new_entries := feedstate.NewEntries(state, item_ids)
+	if len(new_entries) == 0 {
+		log.Printf("Feed unchanged: no entries we haven't processed")
+	}
+	state.NumItems = len(item_ids)
+
+	var feed_items []FeedItem
+	for _, item := range items {
+		if !new_entries[item.ID] || item.Link == "" {
+			continue
+		}
+		feed_items = append(feed_items, FeedItem{Item: item, Feed: feed})
+	}
+	return feed_items, state, nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"git.nunosempere.com/NunoSempere/news/lib/pipeline"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+// FilterAndExpandSource puts a feed item through the shared pipeline, with
+// its feed's freshness window, importance prompt and tags
+func FilterAndExpandSource(item FeedItem, openai_key string, database_url string) (types.ExpandedSource, bool, error) {
+	return pipeline.FilterAndExpand(pipeline.Item{
+		Title:      item.Title,
+		Link:       item.Link,
+		Date:       item.Date,
+		DateMethod: "feed",
+		FreshDays:  item.Feed.FreshDays,
+		Prompt:     item.Feed.Prompt,
+		Tags:       item.Feed.Tags,
+	}, openai_key, database_url)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/feedstate"
+	"git.nunosempere.com/NunoSempere/news/lib/pipeline"
+	"github.com/joho/godotenv"
+)
+
+func pollFeed(feed FeedConfig, openai_key string, pg_database_url string) {
+	ticker := time.NewTicker(feed.cadence)
+	defer ticker.Stop()
+	for ; true; <-ticker.C {
+		items, state, err := FetchFeed(feed, pg_database_url)
+		if err != nil {
+			log.Printf("Error fetching feed %v: %v", feed.Name, err)
+			continue
+		}
+		log.Printf("Feed %v has %d new items", feed.Name, len(items))
+
+		// Items which errored aren't saved, and are retried next poll, see
+		// feedstate.Advance
+		var item_ids []string
+		failed := map[string]bool{}
+		for i, item := range items {
+			log.Printf("\n\nItem #%v/%v [%v]: %v (%v)\n", i+1, len(items), feed.Name, item.Title, item.Link)
+			item_ids = append(item_ids, item.ID)
+			expanded_source, passes_filters, err := FilterAndExpandSource(item, openai_key, pg_database_url)
+			if err == nil && passes_filters {
+				err = pipeline.Save(expanded_source, pg_database_url)
+			}
+			if err != nil && err != pipeline.ErrAlreadySaved {
+				failed[item.ID] = true
+			}
+		}
+		feedstate.Save(feedstate.Advance(state, item_ids, failed), pg_database_url)
+	}
+}
+
+func main() {
+
+	logFile, err := os.OpenFile("sources/feeds/v2.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		log.Fatalf("error opening file: %v", err)
+	}
+	defer logFile.Close()
+	mw := io.MultiWriter(os.Stdout, logFile)
+	log.SetOutput(mw)
+
+	// Get keys
+	err = godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	openai_key := os.Getenv("OPENAI_KEY")
+	pg_database_url := os.Getenv("DATABASE_POOL_URL")
+
+	config, err := LoadConfig("config/feeds.yaml")
+	if err != nil {
+		log.Fatalf("Error loading feeds config: %v", err)
+	}
+
+	// Each feed is polled on its own cadence
+	for _, feed := range config.Feeds {
+		log.Printf("Polling %v every %v", feed.Name, feed.Cadence)
+		go pollFeed(feed, openai_key, pg_database_url)
+	}
+	select {}
+}
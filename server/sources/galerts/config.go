@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"gopkg.in/yaml.v3"
+)
+
+// See config/galerts.yaml
+type AlertConfig struct {
+	Keyword string   `yaml:"keyword"`
+	Url     string   `yaml:"url"`
+	Tags    []string `yaml:"tags"`
+	Prompt  string   `yaml:"prompt"`
+}
+
+type AlertsConfig struct {
+	Alerts []AlertConfig `yaml:"alerts"`
+}
+
+// LoadConfig reads config/galerts.yaml. Alerts without a feed, and repeated
+// keywords or feeds, are left out and returned as problems to report,
+// rather than erroring every cycle. Anything else wrong is an error.
+func LoadConfig(path string) (config AlertsConfig, problems []string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, nil, err
+	}
+	var raw AlertsConfig
+	err = yaml.Unmarshal(data, &raw)
+	if err != nil {
+		return config, nil, fmt.Errorf("Error parsing %v: %v", path, err)
+	}
+	if len(raw.Alerts) == 0 {
+		return config, nil, errors.New("No alerts in " + path)
+	}
+
+	seen_keywords := map[string]bool{}
+	seen_urls := map[string]string{}
+	for i, alert := range raw.Alerts {
+		alert.Keyword = strings.TrimSpace(alert.Keyword)
+		alert.Url = strings.TrimSpace(alert.Url)
+		if alert.Keyword == "" {
+			return config, nil, fmt.Errorf("Alert #%d needs a keyword", i+1)
+		}
+		if alert.Prompt == "" {
+			alert.Prompt = "default"
+		}
+		if _, exists := llm.ImportanceChecks[alert.Prompt]; !exists {
+			return config, nil, fmt.Errorf("Alert %q has an unknown prompt: %q", alert.Keyword, alert.Prompt)
+		}
+
+		key := strings.ToLower(alert.Keyword)
+		switch {
+		case seen_keywords[key]:
+			problems = append(problems, fmt.Sprintf("duplicate keyword %q, skipping the repeat", alert.Keyword))
+			continue
+		case alert.Url == "":
+			problems = append(problems, fmt.Sprintf("keyword %q has no feed url, skipping it", alert.Keyword))
+			continue
+		case seen_urls[alert.Url] != "":
+			problems = append(problems, fmt.Sprintf("keyword %q has the same feed as %q, skipping it", alert.Keyword, seen_urls[alert.Url]))
+			continue
+		}
+		seen_keywords[key] = true
+		seen_urls[alert.Url] = alert.Keyword
+		config.Alerts = append(config.Alerts, alert)
+	}
+	if len(config.Alerts) == 0 {
+		return config, problems, errors.New("No usable alerts in " + path)
+	}
+	return config, problems, nil
+}
+
+// The tag make galerts-stats counts saved sources by
+func (alert AlertConfig) Tag() string {
+	return "alert:" + alert.Keyword
+}
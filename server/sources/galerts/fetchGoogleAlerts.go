@@ -3,8 +3,8 @@ package main
 import (
 	"encoding/xml"
 	"fmt"
+	"git.nunosempere.com/NunoSempere/news/lib/feedstate"
 	"git.nunosempere.com/NunoSempere/news/lib/types"
-	"git.nunosempere.com/NunoSempere/news/lib/web"
 	"log"
 	"net/url"
 )
@@ -15,6 +15,7 @@ type Feed struct {
 }
 
 type Entry struct {
+	ID      string `xml:"id"`
 	Title   string `xml:"title"`
 	Link    link   `xml:"link"`
 	PubDate string `xml:"published"`
@@ -25,36 +26,6 @@ type link struct {
 	Url string `xml:"href,attr"`
 }
 
-func KeywordToRSSFeed(keyword string) (string, error) {
-
-	feedMap := map[string]string{
-		"War":                  "https://www.google.com/alerts/feeds/12823167512648692611/14775069330880237129",
-		"Emergency":            "https://www.google.com/alerts/feeds/12823167512648692611/10752650238419774131",
-		"disaster":             "https://www.google.com/alerts/feeds/12823167512648692611/1731039193900529474",
-		"alert":                "https://www.google.com/alerts/feeds/12823167512648692611/1731039193900529512",
-		"nuclear":              "https://www.google.com/alerts/feeds/12823167512648692611/16302398974188618783",
-		"human-to-human":       "https://www.google.com/alerts/feeds/12823167512648692611/16363046595406729950",
-		"pandemic":             "https://www.google.com/alerts/feeds/12823167512648692611/1689941676777225519",
-		"blockade":             "https://www.google.com/alerts/feeds/12823167512648692611/8592384102868889996",
-		"invasion":             "https://www.google.com/alerts/feeds/12823167512648692611/14400132195257773260",
-		"undersea cables":      "https://www.google.com/alerts/feeds/12823167512648692611/16660286502183277886",
-		"Carrington event":     "https://www.google.com/alerts/feeds/12823167512648692611/17032681478781817561",
-		"mystery pneumonia":    "https://www.google.com/alerts/feeds/12823167512648692611/17032681478781820157",
-		"China Taiwan":         "https://www.google.com/alerts/feeds/12823167512648692611/3055804732710246461",
-		"Russia Ukraine":       "https://www.google.com/alerts/feeds/12823167512648692611/16094280695893389744",
-		"OpenAI announces AGI": "https://www.google.com/alerts/feeds/12823167512648692611/500375972710348852",
-		"military exercise":    "https://www.google.com/alerts/feeds/12823167512648692611/267614087142809738",
-		"Kessler syndrome":     "https://www.google.com/alerts/feeds/12823167512648692611/14873084661553437561",
-		"Cyberattack":          "https://www.google.com/alerts/feeds/12823167512648692611/4267352864131660551",
-	}
-
-	if identifier, exists := feedMap[keyword]; exists && identifier != "" {
-		return identifier, nil
-	}
-
-	return "", fmt.Errorf("Identifier not found in alerts map")
-}
-
 func extractActualLink(encodedURL string) (string, error) {
 
 	/*
@@ -79,50 +50,69 @@ func extractActualLink(encodedURL string) (string, error) {
 	return targetURL, nil
 }
 
-func SearchGoogleAlerts(query string) ([]types.Source, error) {
-	log.Printf("Making google alerts request for query: %s", query)
+// An alert entry, with the feed entry id it came from
+type AlertItem struct {
+	types.Source
+	EntryID string
+}
 
-	url, err := KeywordToRSSFeed(query)
-	if err != nil {
-		return nil, err
-	}
+// SearchGoogleAlerts returns the alert's entries we haven't processed
+// yet. The returned state is saved by the caller once they've been
+// processed, see feedstate.Advance. Entries whose link can't be read are
+// returned without one, so that the caller counts them as failed.
+func SearchGoogleAlerts(alert AlertConfig, database_url string) ([]AlertItem, feedstate.FeedState, error) {
+	log.Printf("Making google alerts request for query: %s", alert.Keyword)
 
-	xml_bytes, err := web.Get(url)
+	xml_bytes, state, unchanged, err := feedstate.Fetch(alert.Url, database_url)
 	if err != nil {
-		return nil, err
+		return nil, state, err
+	}
+	if unchanged {
+		return nil, state, nil
 	}
 
 	var feed Feed
 	err = xml.Unmarshal(xml_bytes, &feed)
 	if err != nil {
 		log.Printf("Error unmarshaling XML: %v\n", err)
-		return nil, err
+		return nil, state, err
+	}
+
+	var entry_ids []string
+	for _, entry := range feed.Entries {
+		entry_ids = append(entry_ids, entry.ID)
 	}
+	state, new_entries := feedstate.NewEntries(state, entry_ids)
+	if len(new_entries) == 0 {
+		log.Printf("Feed unchanged: no entries we haven't processed")
+	}
+	state.NumItems = len(entry_ids)
 
-	var sources []types.Source
+	var items []AlertItem
 	for _, entry := range feed.Entries {
+		if !new_entries[entry.ID] {
+			continue
+		}
 		actual_link, err := extractActualLink(entry.Link.Url)
 		if err != nil {
 			log.Printf("Error parsing url parameter from %v", entry.Link.Url)
-			continue
 		}
-		sources = append(sources, types.Source{Title: entry.Title, Link: actual_link, Date: entry.PubDate})
+		items = append(items, AlertItem{Source: types.Source{Title: entry.Title, Link: actual_link, Date: entry.PubDate}, EntryID: entry.ID})
 	}
 
-	return sources, nil
+	return items, state, nil
 }
 
-func TestGoogleAlerts() {
-	keywords := []string{"War", "Emergency", "disaster"}
-	for _, keyword := range keywords {
-		log.Printf("Testing Google Alerts for keyword: %s", keyword)
-		sources, err := SearchGoogleAlerts(keyword)
+func TestGoogleAlerts(config AlertsConfig, database_url string) {
+	for _, alert := range config.Alerts[:min(3, len(config.Alerts))] {
+		log.Printf("Testing Google Alerts for keyword: %s", alert.Keyword)
+		items, _, err := SearchGoogleAlerts(alert, database_url)
 		if err != nil {
-			log.Printf("Error searching Google Alerts for %s: %v", keyword, err)
+			log.Printf("Error searching Google Alerts for %s: %v", alert.Keyword, err)
 			continue
 		}
-		log.Printf("Found %d sources for keyword %s:", len(sources), keyword)
-		for _, source := range sources {
+		log.Printf("Found %d sources for keyword %s:", len(items), alert.Keyword)
+		for _, source := range items {
 			log.Printf("Title: %s", source.Title)
 			log.Printf("Link: %s", source.Link)
 			log.Printf("Date: %s", source.Date)
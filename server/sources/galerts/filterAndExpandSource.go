@@ -1,9 +1,11 @@
 package main
 
 import (
+	"fmt"
 	"git.nunosempere.com/NunoSempere/news/lib/filters"
 	"git.nunosempere.com/NunoSempere/news/lib/llm"
 	"git.nunosempere.com/NunoSempere/news/lib/readability"
+	"git.nunosempere.com/NunoSempere/news/lib/snapshots"
 	"git.nunosempere.com/NunoSempere/news/lib/types"
 	"log"
 	"time"
@@ -27,43 +29,60 @@ func filterIsFresh(source types.Source) bool {
 	return parsed_time.After(fifteen_days_before) && parsed_time.Before(fifteen_days_after)
 }
 
-func FilterAndExpandSource(source types.Source, openai_key string, database_url string) (types.ExpandedSource, bool) {
-	expanded_source := types.ExpandedSource{Title: source.Title, Link: source.Link, Date: source.Date}
+// FilterAndExpandSource scores the article with the alert's importance
+// prompt, and saves the alert's tags. The error is set if summarizing or
+// scoring failed, in which case the article is worth trying again.
+func FilterAndExpandSource(source types.Source, alert AlertConfig, openai_key string, database_url string) (types.ExpandedSource, bool, error) {
+	tags := append([]string{alert.Tag()}, alert.Tags...)
+	expanded_source := types.ExpandedSource{Title: source.Title, Link: source.Link, Date: source.Date, Tags: tags}
 
 	is_dupe := filters.IsDupe(source, database_url)
 	if is_dupe {
-		return expanded_source, false
+		return expanded_source, false, nil
 	}
 
 	is_fresh := filterIsFresh(source)
 	if !is_fresh {
-		return expanded_source, false
+		return expanded_source, false, nil
 	}
 
 	is_good_host := filters.IsGoodHost(source)
 	if !is_good_host {
-		return expanded_source, false
+		return expanded_source, false, nil
 	}
 
 	expanded_source.Title = filters.CleanTitle(expanded_source.Title)
 
-	content, err := readability.GetArticleContent(source.Link)
+	article, err := readability.GetArticle(source.Link)
 	if err != nil {
-		return expanded_source, false
+		return expanded_source, false, nil
 	}
-	summary, err := llm.Summarize(content, openai_key)
-	if err != nil {
-		return expanded_source, false
+
+	// Keep the page we scored, so that it can be re-scored or shown later
+	snapshot_id, err := snapshots.Save(source.Link, article.Html, article.Content, database_url)
+	if err == nil {
+		expanded_source.SnapshotID = snapshot_id
+	}
+	var summary string
+	if article.TitleOnly {
+		// Paywalled: score on the title and lede rather than dropping it
+		summary = "[Paywalled, lede only] " + article.Content
+	} else {
+		summary, err = llm.Summarize(article.Content, openai_key)
+		if err != nil {
+			return expanded_source, false, err
+		}
 	}
 	expanded_source.Summary = summary
 
 	existential_importance_snippet := "# " + source.Title + "\n\n" + summary
-	existential_importance_box, err := llm.CheckExistentialImportance(existential_importance_snippet, openai_key)
+	existential_importance_box, err := llm.ImportanceChecks[alert.Prompt](existential_importance_snippet, openai_key)
 	if err != nil || existential_importance_box == nil {
-		return expanded_source, false
+		return expanded_source, false, fmt.Errorf("importance check failed: %v", err)
 	}
 	expanded_source.ImportanceBool = existential_importance_box.ExistentialImportanceBool
 	expanded_source.ImportanceReasoning = existential_importance_box.ExistentialImportanceReasoning
+	expanded_source.ImportancePrompt = alert.Prompt
 
-	return expanded_source, expanded_source.ImportanceBool
+	return expanded_source, expanded_source.ImportanceBool, nil
 }
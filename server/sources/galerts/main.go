@@ -1,6 +1,7 @@
 package main
 
 import (
+	"git.nunosempere.com/NunoSempere/news/lib/feedstate"
 	"github.com/joho/godotenv"
 	"io"
 	"log"
@@ -8,8 +9,24 @@ import (
 	"time"
 )
 
+/*
+make run-galerts polls the alerts in config/galerts.yaml. make galerts-stats
+runs it with the stats argument, which prints each alert's item counts and
+last successful fetch instead.
+*/
+
 func main() {
 
+	if len(os.Args) > 1 && os.Args[1] == "stats" {
+		godotenv.Load()
+		config, problems, err := LoadConfig("config/galerts.yaml")
+		if err != nil {
+			log.Fatalf("Error loading galerts config: %v", err)
+		}
+		PrintStats(config, problems, os.Getenv("DATABASE_POOL_URL"))
+		return
+	}
+
 	logFile, err := os.OpenFile("sources/galerts/v2.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 	if err != nil {
 		log.Fatalf("error opening file: %v", err)
@@ -26,12 +43,21 @@ func main() {
 	openai_key := os.Getenv("OPENAI_KEY")
 	pg_database_url := os.Getenv("DATABASE_POOL_URL")
 
-	keywords := []string{"War", "Emergency", "disaster", "alert", "nuclear", "combat duty", "human-to-human", "pandemic", "blockade", "invasion", "undersea cables", "nuclear", "Carrington event", "mystery pneumonia", "Taiwan", "Ukraine", "OpenAI announces AGI", "AI rights", "military exercise", "Kessler syndrome", "Cyberattack"}
+	config, problems, err := LoadConfig("config/galerts.yaml")
+	if err != nil {
+		log.Fatalf("Error loading galerts config: %v", err)
+	}
+	for _, problem := range problems {
+		log.Printf("galerts config: %v", problem)
+	}
+	log.Printf("Polling %d alerts", len(config.Alerts))
+
 	for true {
 		log.Println("(Re)starting Google Alerts keyword loop")
-		for _, keyword := range keywords {
+		for _, alert := range config.Alerts {
+			keyword := alert.Keyword
 			log.Printf("Keyword: %v", keyword)
-			articles, err := SearchGoogleAlerts(keyword)
+			articles, state, err := SearchGoogleAlerts(alert, pg_database_url)
 			if err != nil {
 				log.Printf("Google Alerts error: %v", err)
 				continue
@@ -39,14 +65,30 @@ func main() {
 
 			log.Printf("Number of articles in keyword: %v", len(articles))
 
+			// Articles whose link couldn't be read, or whose summary or
+			// importance check errored, aren't saved, and are retried next
+			// cycle, see feedstate.Advance
+			var entry_ids []string
+			failed := map[string]bool{}
 			for i, article := range articles {
 				log.Printf("\n")
 				log.Printf("Article #%v/%v [keyword \"%v\"]: %v (%v)", i, len(articles), keyword, article.Title, article.Date)
-				expanded_source, passes_filters := FilterAndExpandSource(article, openai_key, pg_database_url)
+				entry_ids = append(entry_ids, article.EntryID)
+				if article.Link == "" {
+					failed[article.EntryID] = true
+					continue
+				}
+				expanded_source, passes_filters, err := FilterAndExpandSource(article.Source, alert, openai_key, pg_database_url)
+				if err != nil {
+					log.Printf("Will retry %v: %v", article.Link, err)
+					failed[article.EntryID] = true
+					continue
+				}
 				if passes_filters {
 					SaveSource(expanded_source)
 				}
 			}
+			feedstate.Save(feedstate.Advance(state, entry_ids, failed), pg_database_url)
 		}
 		log.Printf("Finished Google Alerts batch, pausing for half an hour")
 		time.Sleep(1800 * time.Second) // stagger a little bit
@@ -25,11 +25,15 @@ func SaveSource(source types.ExpandedSource) {
 		return
 	}
 
+	var snapshot_id *int64
+	if source.SnapshotID != 0 {
+		snapshot_id = &source.SnapshotID
+	}
 	_, err = conn.Exec(context.Background(), `
-        INSERT INTO sources (title, link, date, summary, importance_bool, importance_reasoning)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        INSERT INTO sources (title, link, date, summary, importance_bool, importance_reasoning, importance_prompt, snapshot_id, tags)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
         ON CONFLICT (link) DO NOTHING
-    `, source.Title, source.Link, date, source.Summary, source.ImportanceBool, source.ImportanceReasoning)
+    `, source.Title, source.Link, date, source.Summary, source.ImportanceBool, source.ImportanceReasoning, source.ImportancePrompt, snapshot_id, source.Tags)
 
 	if err != nil {
 		log.Printf("Error saving source to database: %v\n", err)
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/feedstate"
+	"github.com/jackc/pgx/v5"
+)
+
+// savedPerTag counts the sources saved under each alert:<keyword> tag
+func savedPerTag(database_url string) (map[string]int, error) {
+	conn, err := pgx.Connect(context.Background(), database_url)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close(context.Background())
+
+	rows, err := conn.Query(context.Background(), `
+		SELECT tag, COUNT(*) FROM sources, unnest(tags) AS tag
+		WHERE tag LIKE 'alert:%'
+		GROUP BY tag
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := map[string]int{}
+	for rows.Next() {
+		var tag string
+		var count int
+		if err := rows.Scan(&tag, &count); err != nil {
+			return nil, err
+		}
+		counts[tag] = count
+	}
+	return counts, rows.Err()
+}
+
+// PrintStats lists each alert's item counts and last successful fetch, for
+// make galerts-stats
+func PrintStats(config AlertsConfig, problems []string, database_url string) {
+	for _, problem := range problems {
+		fmt.Printf("Config: %s\n", problem)
+	}
+	if len(problems) > 0 {
+		fmt.Println()
+	}
+
+	saved, err := savedPerTag(database_url)
+	if err != nil {
+		log.Printf("Error counting saved sources: %v", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Keyword\tIn feed\tNew items seen\tSaved\tLast successful fetch")
+	for _, alert := range config.Alerts {
+		state, err := feedstate.Load(alert.Url, database_url)
+		last_success := "never"
+		if err != nil {
+			last_success = "unknown"
+		} else if !state.LastSuccessAt.IsZero() {
+			last_success = fmt.Sprintf("%v (%v ago)", state.LastSuccessAt.Format(time.RFC3339), time.Since(state.LastSuccessAt).Round(time.Minute))
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\n", alert.Keyword, state.NumItems, state.NumNewItems, saved[alert.Tag()], last_success)
+	}
+	w.Flush()
+}
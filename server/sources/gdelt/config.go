@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"git.nunosempere.com/NunoSempere/news/lib/gdelt"
+	"git.nunosempere.com/NunoSempere/news/lib/gkg"
+	"gopkg.in/yaml.v3"
+)
+
+// See config/gdelt.yaml
+type EventsConfig struct {
+	QuadClasses       []int      `yaml:"quad_classes"`
+	GoldsteinMax      float64    `yaml:"goldstein_max"`
+	MinNumSources     int        `yaml:"min_num_sources"`
+	MinNumArticles    int        `yaml:"min_num_articles"`
+	ActorCountries    []string   `yaml:"actor_countries"`
+	ActorCountryPairs [][]string `yaml:"actor_country_pairs"`
+}
+
+type GKGConfig struct {
+	Triggers []gkg.Trigger `yaml:"triggers"`
+}
+
+type BackfillConfig struct {
+	MaxHours int `yaml:"max_hours"`
+}
+
+type TranslingualConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+type Config struct {
+	Events       EventsConfig       `yaml:"events"`
+	GKG          GKGConfig          `yaml:"gkg"`
+	Backfill     BackfillConfig     `yaml:"backfill"`
+	Translingual TranslingualConfig `yaml:"translingual"`
+}
+
+// What we used before triggers were configurable
+var defaultTriggers = []gkg.Trigger{
+	{Name: "mass casualties", Condition: gkg.Condition{Any: []gkg.Condition{
+		{Count: &gkg.CountCondition{Type: "KILL", Min: 101}},
+		{Count: &gkg.CountCondition{Type: "WOUND", Min: 1001}},
+	}}},
+}
+
+func LoadConfig(path string) (Config, error) {
+	config := Config{
+		Events:   EventsConfig{QuadClasses: []int{gdelt.MaterialConflict}, GoldsteinMax: -7},
+		Backfill: BackfillConfig{MaxHours: 24},
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return config, fmt.Errorf("Error parsing %v: %v", path, err)
+	}
+	for _, pair := range config.Events.ActorCountryPairs {
+		if len(pair) != 2 {
+			return config, fmt.Errorf("actor_country_pairs should be pairs, got: %v", pair)
+		}
+	}
+	if config.Backfill.MaxHours < 0 {
+		return config, fmt.Errorf("backfill max_hours can't be negative, got: %d", config.Backfill.MaxHours)
+	}
+	if len(config.GKG.Triggers) == 0 {
+		config.GKG.Triggers = defaultTriggers
+	}
+	for _, trigger := range config.GKG.Triggers {
+		if err := trigger.Validate(); err != nil {
+			return config, err
+		}
+	}
+	return config, nil
+}
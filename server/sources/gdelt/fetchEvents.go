@@ -0,0 +1,87 @@
+package main
+
+import (
+	"log"
+	"slices"
+
+	"git.nunosempere.com/NunoSempere/news/lib/gdelt"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+// An event's source url, with the event it was found in
+type EventSource struct {
+	types.Source
+	Event    gdelt.Event
+	Coverage gdelt.Coverage
+}
+
+func (c EventsConfig) actorsMatch(event gdelt.Event) bool {
+	if len(c.ActorCountries) == 0 && len(c.ActorCountryPairs) == 0 {
+		return true
+	}
+	a, b := event.Actor1.CountryCode, event.Actor2.CountryCode
+	if a == "" || b == "" || a == b {
+		return false
+	}
+	if slices.Contains(c.ActorCountries, a) && slices.Contains(c.ActorCountries, b) {
+		return true
+	}
+	for _, pair := range c.ActorCountryPairs {
+		if (pair[0] == a && pair[1] == b) || (pair[0] == b && pair[1] == a) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c EventsConfig) Matches(event gdelt.Event) bool {
+	return slices.Contains(c.QuadClasses, event.QuadClass) &&
+		event.GoldsteinScale <= c.GoldsteinMax &&
+		event.NumSources >= c.MinNumSources &&
+		event.NumArticles >= c.MinNumArticles &&
+		c.actorsMatch(event)
+}
+
+// FilterEvents keeps the events which match the config, one per url: the
+// same article is often coded as several events, so we keep whichever is
+// the most severe
+func FilterEvents(events []gdelt.Event, config EventsConfig) []EventSource {
+	var sources []EventSource
+	index_by_url := map[string]int{}
+	for _, event := range events {
+		if event.SourceURL == "" || !config.Matches(event) {
+			continue
+		}
+		i, seen := index_by_url[event.SourceURL]
+		if seen {
+			if event.GoldsteinScale < sources[i].Event.GoldsteinScale {
+				sources[i].Event = event
+			}
+			continue
+		}
+		index_by_url[event.SourceURL] = len(sources)
+		sources = append(sources, EventSource{
+			// Events have no title; we use the article's, once we've fetched it
+			Source: types.Source{Link: event.SourceURL, Date: event.DateAdded.Format("20060102150405")},
+			Event:  event,
+		})
+	}
+	return sources
+}
+
+func SearchEvents(update gdelt.Update, config EventsConfig) ([]EventSource, error) {
+	log.Printf("events link: %v", update.ExportURL)
+	export, err := gdelt.DownloadZip(update.ExportURL)
+	if err != nil {
+		return nil, err
+	}
+	defer export.Close()
+
+	events, err := gdelt.ParseEvents(export)
+	if err != nil {
+		return nil, err
+	}
+	sources := FilterEvents(events, config)
+	log.Printf("%d of %d events pass the events filters", len(sources), len(events))
+	return sources, nil
+}
@@ -1,17 +1,13 @@
 package main
 
 import (
-	"archive/zip"
-	"bufio"
-	"bytes"
+	"errors"
 	"fmt"
+	"git.nunosempere.com/NunoSempere/news/lib/gdelt"
+	"git.nunosempere.com/NunoSempere/news/lib/gkg"
 	"git.nunosempere.com/NunoSempere/news/lib/types"
 	"io"
 	"log"
-	"net/http"
-	"regexp"
-	"strconv"
-	"strings"
 	"time"
 )
 
@@ -19,194 +15,97 @@ type GKGNode struct {
 	Title    string
 	Link     string
 	GKG_Date string
+	Trigger  string
+	Language string
 }
 
-func cut(s string, delimiter string, n int) (string, error) {
-	parts := strings.Split(s, delimiter)
-	if len(parts) < n {
-		err := fmt.Errorf("Expected at least %d parts, got: %d", n, len(parts))
-		log.Println(err)
-		return "", err
-	}
-	return parts[n-1], nil
+// A GKG article, with the trigger it matched, the language it was written
+// in, and how widely it's being reported
+type GKGSource struct {
+	types.Source
+	Trigger  string
+	Language string
+	Coverage gdelt.Coverage
 }
 
-func findGKGNodeTitle(data string) (string, error) {
-	// Compile a regular expression that captures the content between <PAGE_TITLE> and </PAGE_TITLE>
-	re, err := regexp.Compile("<PAGE_TITLE>(.*?)</PAGE_TITLE>")
-	if err != nil {
-		log.Printf("Error in findGKGNodeTitle: %v", err)
-		return "", err
-	}
-	matches := re.FindStringSubmatch(data)
-	if len(matches) < 2 {
-		error_msg := "In findGKGNodeTitle, title not found"
-		log.Printf("%s", error_msg)
-		return "", fmt.Errorf(error_msg)
+// How a GKG file went, for the logs
+type GKGStats struct {
+	Records     int
+	ParseErrors int
+	Matched     int
+	ByTrigger   map[string]int
+	Elapsed     time.Duration
+}
+
+func (s GKGStats) String() string {
+	per_second := 0.0
+	if s.Elapsed > 0 {
+		per_second = float64(s.Records+s.ParseErrors) / s.Elapsed.Seconds()
 	}
-	return matches[1], nil
+	return fmt.Sprintf("%d records, %d unparseable, %d matched %v, in %v (%.0f lines/s)",
+		s.Records, s.ParseErrors, s.Matched, s.ByTrigger, s.Elapsed.Round(time.Millisecond), per_second)
 }
 
-func processGKGLines(r io.Reader) ([]GKGNode, error) {
+// processGKGLines evaluates every record in a GKG file against the
+// triggers. Unparseable lines are counted and skipped; an error means the
+// file couldn't be read to the end.
+func processGKGLines(r io.Reader, triggers []gkg.Trigger) ([]GKGNode, GKGStats, error) {
 	var nodes []GKGNode
-	scanner := bufio.NewScanner(r)
-	buf := make([]byte, 0, 64*1024) // otherwise some lines are too long
-	scanner.Buffer(buf, 1024*1024)
-
-	i := 0
-	for scanner.Scan() {
-		if i > 10000 {
-			break
-		}
-		i++
-		line := scanner.Text()
-
-		date, err := cut(line, "\t", 2)
-		if err != nil {
-			return nil, err
-		}
-
-		link, err := cut(line, "\t", 5)
-		if err != nil {
-			return nil, err
-		}
-
-		counts, err := cut(line, "\t", 6)
-		if err != nil {
-			return nil, err
-		}
-		xml, err := cut(line, "\t", 27)
-		if err != nil {
-			return nil, err
+	stats := GKGStats{ByTrigger: map[string]int{}}
+	start := time.Now()
+
+	for record, err := range gkg.Records(r) {
+		var line_err *gkg.LineError
+		if errors.As(err, &line_err) {
+			stats.ParseErrors++
+			if stats.ParseErrors <= 10 {
+				log.Printf("Skipping GKG record: %v", err)
+			}
+			continue
 		}
-		title, err := findGKGNodeTitle(xml)
 		if err != nil {
-			title = "New GKG node with > 100 deaths or > 1K wounded; though GKG can be mistaken"
+			stats.Elapsed = time.Since(start)
+			return nodes, stats, fmt.Errorf("reading GKG file after %d records: %w", stats.Records, err)
 		}
+		stats.Records++
 
-		report := false
-		for _, count := range strings.Split(counts, ";") {
-			count_parts := strings.Split(count, "#")
-			if len(count_parts) < 2 {
-				break
-			}
-			count_type := count_parts[0]
-			count_num, err := strconv.Atoi(count_parts[1])
-			// fmt.Printf("\t\t%d\n", count_num)
-			if err != nil {
-				// Not an error; some items don't have to have counts.
-				break
-			}
-			if count_type == "KILL" && count_num > 100 {
-				// fmt.Printf("counts")
-				report = true
-			}
-			if count_type == "WOUND" && count_num > 1000 {
-				// fmt.Printf("counts")
-				report = true
-			}
-		}
+		trigger, report := gkg.FirstMatch(triggers, record)
 		if report {
-			/*
-				if err == nil {
-					title = "New GKG node with > 100 killed or > 1000 wounded—though note that GKG can be unreliable"
-					fmt.Printf("Title not found in line: %s", line)
-				}
-			*/
-			new_node := GKGNode{Title: title, Link: link, GKG_Date: date}
+			stats.Matched++
+			stats.ByTrigger[trigger.Name]++
+			title := record.Title()
+			if title == "" {
+				title = "New GKG node matching " + trigger.Name + "; though GKG can be mistaken"
+			}
+			new_node := GKGNode{Title: title, Link: record.DocumentIdentifier, GKG_Date: record.Date.Format("20060102150405"), Trigger: trigger.Name, Language: record.SourceLanguage()}
 			nodes = append(nodes, new_node)
-			// log.Printf("Node %v\n", new_node)
-			// fmt.Printf("%s\n", link)
-			// fmt.Printf("%s\n\n", line)
-			// fmt.Printf("\t%s\n", counts)
 		}
-
-	}
-	if err := scanner.Err(); err != nil {
-		fmt.Printf("Error reading content: %s\n", err)
 	}
-
-	return nodes, nil
+	stats.Elapsed = time.Since(start)
+	return nodes, stats, nil
 }
 
-func SearchGKG() ([]types.Source, error) {
-	// Fetch last update
-	resp, err := http.Get("http://data.gdeltproject.org/gdeltv2/lastupdate.txt")
+func SearchGKG(update gdelt.Update, triggers []gkg.Trigger) ([]GKGSource, error) {
+	// Download and decompress as we go
+	log.Printf("gkg link: %v", update.GKGURL)
+	zipped_file, err := gdelt.DownloadZip(update.GKGURL)
 	if err != nil {
-		return nil, fmt.Errorf("fetching lastupdate.txt: %w", err)
+		return nil, err
 	}
-
-	// Extract link of zipfile
-	defer resp.Body.Close()
-	scanner := bufio.NewScanner(resp.Body)
-	var gkg_link string
-	line_count := 0
-	for scanner.Scan() {
-		if line_count == 2 {
-			line := scanner.Text()
-			gkg_link, err = cut(line, " ", 3)
-			if err != nil {
-				return nil, err
-			}
-			break
-		}
-		line_count++
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("reading response: %w", err)
-	} else if line_count < 2 {
-		return nil, fmt.Errorf("lastupdate.txt doesn't have three lines")
-	}
-
-	// Download zipfile
-	time.Sleep(30 * time.Second)
-	log.Printf("gkg link: %v", gkg_link)
-	gkg_resp, err := http.Get(gkg_link)
-	log.Printf("http response: %v", gkg_resp)
-
-	if err != nil {
-		return nil, fmt.Errorf("downloading file: %w", err)
-	}
-
-	defer gkg_resp.Body.Close()
-
-	// read zipfile
-	gkg_data, err := io.ReadAll(gkg_resp.Body)
-
-	if err != nil {
-		return nil, fmt.Errorf("reading download response body: %w", err)
-	}
-
-	gkg_reader := bytes.NewReader(gkg_data)
-
-	// Opening zip content
-	zip_reader, err := zip.NewReader(gkg_reader, int64(len(gkg_data)))
-
-	if err != nil {
-		gkg_reader.Seek(0, 0)
-		return nil, fmt.Errorf("Error reading zip content: %w\ndata: %v", err, gkg_data)
-	}
-
-	if len(zip_reader.File) == 0 {
-		return nil, fmt.Errorf("Zip file is empty")
-	}
-
-	zipped_file, err := zip_reader.File[0].Open()
-
-	if err != nil {
-		return nil, fmt.Errorf("opening zipped file: %w", err)
-	}
-
 	defer zipped_file.Close()
 
-	// return processGKGLines(zipped_file)
-	var sources []types.Source
-	nodes, err := processGKGLines(zipped_file)
+	nodes, stats, err := processGKGLines(zipped_file, triggers)
+	log.Printf("GKG file %v: %v", update.GKGURL, stats)
 	if err != nil {
-		return sources, nil
+		return nil, err
 	}
-	for i, _ := range nodes {
-		sources = append(sources, types.Source{Title: nodes[i].Title, Link: nodes[i].Link, Date: nodes[i].GKG_Date})
+	var sources []GKGSource
+	for i := range nodes {
+		sources = append(sources, GKGSource{
+			Source:   types.Source{Title: nodes[i].Title, Link: nodes[i].Link, Date: nodes[i].GKG_Date},
+			Trigger:  nodes[i].Trigger,
+			Language: nodes[i].Language,
+		})
 	}
 	return sources, nil
 }
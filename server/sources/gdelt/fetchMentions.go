@@ -0,0 +1,72 @@
+package main
+
+import (
+	"log"
+	"slices"
+	"sort"
+
+	"git.nunosempere.com/NunoSempere/news/lib/gdelt"
+)
+
+// UpdateCoverage aggregates an interval's mentions file and saves it, so
+// that its GKG and events batches can be ranked by how widely their stories
+// are being reported
+func UpdateCoverage(update gdelt.Update, database_url string) (gdelt.Window, error) {
+	log.Printf("mentions link: %v", update.MentionsURL)
+	mentions_file, err := gdelt.DownloadZip(update.MentionsURL)
+	if err != nil {
+		return gdelt.Window{}, err
+	}
+	defer mentions_file.Close()
+
+	mentions, err := gdelt.ParseMentions(mentions_file)
+	if err != nil {
+		return gdelt.Window{}, err
+	}
+	window := gdelt.Aggregate(mentions)
+	log.Printf("%d mentions of %d events", len(mentions), len(window.ByEvent))
+	err = gdelt.SaveWindow(window, database_url)
+
+	// A week is plenty to tell rising stories apart
+	gdelt.PruneCoverage(7, database_url)
+	return window, err
+}
+
+// Most covered first, with rising stories ahead of everything else
+func lessCovered(a gdelt.Coverage, b gdelt.Coverage) bool {
+	if a.Rising() != b.Rising() {
+		return b.Rising()
+	}
+	return a.NumSources < b.NumSources
+}
+
+func RankGKGSources(sources []GKGSource, window gdelt.Window, database_url string) []GKGSource {
+	event_ids := make([][]int64, len(sources))
+	for i := range sources {
+		event_ids[i] = window.EventsFor(sources[i].Link)
+	}
+	coverages, _ := gdelt.GetCoverages(event_ids, database_url)
+	for i := range sources {
+		sources[i].Coverage = coverages[i]
+	}
+	sort.SliceStable(sources, func(i, j int) bool {
+		return lessCovered(sources[j].Coverage, sources[i].Coverage)
+	})
+	return sources
+}
+
+func RankEventSources(event_sources []EventSource, window gdelt.Window, database_url string) []EventSource {
+	event_ids := make([][]int64, len(event_sources))
+	for i := range event_sources {
+		// Clipped, so that events with the same source url don't share a backing array
+		event_ids[i] = append(slices.Clip(window.EventsFor(event_sources[i].Link)), event_sources[i].Event.GlobalEventID)
+	}
+	coverages, _ := gdelt.GetCoverages(event_ids, database_url)
+	for i := range event_sources {
+		event_sources[i].Coverage = coverages[i]
+	}
+	sort.SliceStable(event_sources, func(i, j int) bool {
+		return lessCovered(event_sources[j].Coverage, event_sources[i].Coverage)
+	})
+	return event_sources
+}
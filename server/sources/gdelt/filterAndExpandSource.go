@@ -1,11 +1,15 @@
 package main
 
 import (
+	"fmt"
 	"git.nunosempere.com/NunoSempere/news/lib/filters"
+	"git.nunosempere.com/NunoSempere/news/lib/gdelt"
 	"git.nunosempere.com/NunoSempere/news/lib/llm"
 	"git.nunosempere.com/NunoSempere/news/lib/readability"
+	"git.nunosempere.com/NunoSempere/news/lib/snapshots"
 	"git.nunosempere.com/NunoSempere/news/lib/types"
 	"log"
+	"strings"
 	"time"
 )
 
@@ -32,8 +36,47 @@ func filterIsFresh(source types.Source) bool {
 	return is_fresh
 }
 
-func FilterAndExpandSource(source types.Source, openai_key string, database_url string) (types.ExpandedSource, bool) {
-	expanded_source := types.ExpandedSource{Title: source.Title, Link: source.Link, Date: source.Date}
+// FilterAndExpandSource tells the importance check which trigger the GKG
+// article matched, and saves it and the article's language as tags
+func FilterAndExpandSource(gkg_source GKGSource, openai_key string, database_url string) (types.ExpandedSource, bool) {
+	tags := []string{"gdelt-gkg", "trigger:" + gkg_source.Trigger}
+	if gkg_source.Language != "" && gkg_source.Language != "eng" {
+		tags = append(tags, "gdelt-translingual", "lang:"+gkg_source.Language)
+	}
+	return filterAndExpand(gkg_source.Source, gkg_source.Coverage, "GDELT GKG trigger: "+gkg_source.Trigger, tags, gkg_source.Language, openai_key, database_url)
+}
+
+// FilterAndExpandEvent passes the event's metadata on to the importance
+// check, and saves it as tags
+func FilterAndExpandEvent(event_source EventSource, openai_key string, database_url string) (types.ExpandedSource, bool) {
+	event := event_source.Event
+	tags := []string{
+		"gdelt-event",
+		fmt.Sprintf("quadclass:%d", event.QuadClass),
+		"cameo:" + event.EventCode,
+		fmt.Sprintf("goldstein:%.1f", event.GoldsteinScale),
+	}
+	for _, country := range []string{event.Actor1.CountryCode, event.Actor2.CountryCode} {
+		if country != "" {
+			tags = append(tags, "actor:"+country)
+		}
+	}
+	return filterAndExpand(event_source.Source, event_source.Coverage, "GDELT event: "+event.Describe(), tags, "eng", openai_key, database_url)
+}
+
+// filterAndExpand translates the title and summarizes in English when the
+// article's language, an ISO 639-2 code, isn't English
+func filterAndExpand(source types.Source, coverage gdelt.Coverage, context string, tags []string, language string, openai_key string, database_url string) (types.ExpandedSource, bool) {
+	is_translingual := language != "" && language != "eng"
+	expanded_source := types.ExpandedSource{Title: source.Title, Link: source.Link, Date: source.Date, Tags: tags}
+	if coverage.NumSources > 0 {
+		expanded_source.NumReportingSources = coverage.NumSources
+		context = strings.TrimSpace(context + fmt.Sprintf("\nReported by %d sources", coverage.NumSources))
+		if coverage.Rising() {
+			expanded_source.Tags = append(expanded_source.Tags, "rising-coverage")
+			context += fmt.Sprintf(", %d of them in the last hour", coverage.RecentSources)
+		}
+	}
 
 	is_dupe := filters.IsDupe(source, database_url)
 	if is_dupe {
@@ -49,25 +92,60 @@ func FilterAndExpandSource(source types.Source, openai_key string, database_url
 	if !is_good_host {
 		return expanded_source, false
 	}
-	expanded_source.Title = filters.CleanTitle(expanded_source.Title)
-
-	content, err := readability.GetArticleContent(source.Link)
+	article, err := readability.GetArticle(source.Link)
 	if err != nil {
 		return expanded_source, false
 	}
-	summary, err := llm.Summarize(content, openai_key)
-	if err != nil {
-		return expanded_source, false
+	if expanded_source.Title == "" {
+		expanded_source.Title = article.Title
+	}
+	if is_translingual {
+		translated_title, err := llm.TranslateString(expanded_source.Title, openai_key)
+		if err != nil {
+			log.Printf("%v", err)
+			return expanded_source, false
+		}
+		log.Printf("Translated title (%v): %s", language, translated_title)
+		expanded_source.Title = translated_title
+	}
+	expanded_source.Title = filters.CleanTitle(expanded_source.Title)
+	if expanded_source.Title == "" {
+		// Never save an empty title, which later untitled events would match
+		expanded_source.Title = source.Link
+	}
+
+	// Keep the page we scored, so that it can be re-scored or shown later
+	snapshot_id, err := snapshots.Save(source.Link, article.Html, article.Content, database_url)
+	if err == nil {
+		expanded_source.SnapshotID = snapshot_id
+	}
+	var summary string
+	if article.TitleOnly {
+		// Paywalled: score on the title and lede rather than dropping it
+		summary = "[Paywalled, lede only] " + article.Content
+	} else {
+		content := article.Content
+		if is_translingual {
+			content += "\n\nThis article isn't in English: give the gist in idiomatic English, rather than selecting the most important phrases in the original language"
+		}
+		summary, err = llm.Summarize(content, openai_key)
+		if err != nil {
+			return expanded_source, false
+		}
 	}
 	expanded_source.Summary = summary
 
-	existential_importance_snippet := "# " + source.Title + "\n\n" + summary
+	existential_importance_snippet := "# " + expanded_source.Title + "\n\n" + summary
+	if context != "" {
+		existential_importance_snippet += "\n\n" + context
+	}
 	existential_importance_box, err := llm.CheckExistentialImportance(existential_importance_snippet, openai_key)
 	if err != nil || existential_importance_box == nil {
 		return expanded_source, false
 	}
 	expanded_source.ImportanceBool = existential_importance_box.ExistentialImportanceBool
 	expanded_source.ImportanceReasoning = existential_importance_box.ExistentialImportanceReasoning
+	expanded_source.ImportancePrompt = "default"
 
 	if expanded_source.ImportanceBool {
 		log.Printf("Article might be important")
@@ -1,13 +1,25 @@
 package main
 
 import (
-	"github.com/joho/godotenv"
 	"io"
 	"log"
 	"os"
+	"slices"
+	"sync"
 	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/gdelt"
+	"github.com/joho/godotenv"
 )
 
+// Only one catch up per stream runs at a time. A tick which lands while one
+// is still running leaves its interval to the next tick, which will
+// backfill it.
+var processing = map[string]*sync.Mutex{
+	gdelt.English.Name:      {},
+	gdelt.Translingual.Name: {},
+}
+
 func main() {
 
 	// Initialize logging
@@ -27,52 +39,201 @@ func main() {
 	openai_key := os.Getenv("OPENAI_KEY")
 	pg_database_url := os.Getenv("DATABASE_POOL_URL")
 
-	// Search gkg
-	ticker_gkg := time.NewTicker(15 * time.Minute)
-	defer ticker_gkg.Stop()
-	for ; true; <-ticker_gkg.C {
+	config, err := LoadConfig("config/gdelt.yaml")
+	if err != nil {
+		log.Fatalf("Error loading gdelt config: %v", err)
+	}
+
+	// Search gkg and events
+	ticker := time.NewTicker(gdelt.Interval)
+	defer ticker.Stop()
+	for ; true; <-ticker.C {
+		go catchUp(gdelt.English, config, openai_key, pg_database_url)
+		if config.Translingual.Enabled {
+			go catchUp(gdelt.Translingual, config, openai_key, pg_database_url)
+		}
+	}
+}
+
+// pendingIntervals returns the stream's intervals after last_processed,
+// oldest first, going back at most max_hours
+func pendingIntervals(stream gdelt.Stream, last_processed time.Time, max_hours int) ([]gdelt.Update, error) {
+	latest, err := gdelt.LastUpdate(stream)
+	if err != nil {
+		return nil, err
+	}
+	if !latest.Timestamp.After(last_processed) {
+		return nil, nil
+	}
+	// On the first run there is nothing to catch up on
+	if last_processed.IsZero() {
+		return []gdelt.Update{latest}, nil
+	}
+
+	since := latest.Timestamp.Add(-time.Duration(max_hours) * time.Hour)
+	if last_processed.After(since) {
+		since = last_processed
+	} else {
+		log.Printf("Last processed %v GDELT interval was %v; only going back %d hours", stream.Name, last_processed, max_hours)
+	}
+	if latest.Timestamp.Sub(since) <= gdelt.Interval {
+		return []gdelt.Update{latest}, nil
+	}
+	missed, err := gdelt.Intervals(stream, since, latest.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Backfilling %d missed %v GDELT intervals since %v", len(missed), stream.Name, since)
+	return append(missed, latest), nil
+}
+
+// catchUp processes every pending interval in order. Progress is kept
+// separately for the GKG and the events file, and each stops at the first
+// interval where it fails, so that the next tick retries just that part,
+// and the other part's articles aren't scored again.
+func catchUp(stream gdelt.Stream, config Config, openai_key string, database_url string) {
+	if !processing[stream.Name].TryLock() {
+		log.Printf("Still processing earlier %v GDELT intervals; will catch up on this one later", stream.Name)
+		return
+	}
+	defer processing[stream.Name].Unlock()
+
+	// Translingual intervals only use the GKG: coverage is kept for the
+	// English stream's events
+	parts := []string{gdelt.GKGPart}
+	if stream == gdelt.English {
+		parts = append(parts, gdelt.EventsPart)
+	}
+	progress := map[string]time.Time{}
+	var oldest time.Time
+	for _, part := range parts {
+		last_processed, err := gdelt.LoadProgress(stream, part, database_url)
+		if err != nil {
+			log.Printf("GDELT error: %v", err)
+			return
+		}
+		progress[part] = last_processed
+		if oldest.IsZero() || (!last_processed.IsZero() && last_processed.Before(oldest)) {
+			oldest = last_processed
+		}
+	}
+
+	updates, err := pendingIntervals(stream, oldest, config.Backfill.MaxHours)
+	if err != nil {
+		log.Printf("GDELT error: %v", err)
+		return
+	}
+	failed := map[string]bool{}
+	for i, update := range updates {
+		var todo []string
+		for _, part := range parts {
+			is_pending := update.Timestamp.After(progress[part])
+			if progress[part].IsZero() {
+				// On the first run there is nothing to catch up on
+				is_pending = i == len(updates)-1
+			}
+			if is_pending && !failed[part] {
+				todo = append(todo, part)
+			}
+		}
+		if len(todo) == 0 {
+			continue
+		}
+		log.Printf("Processing %v GDELT interval %v (%d/%d): %v", stream.Name, update.Timestamp, i+1, len(updates), todo)
+		errs := processInterval(update, todo, config, openai_key, database_url)
+		for _, part := range todo {
+			if errs[part] != nil {
+				log.Printf("GDELT %v interval %v failed, will retry: %v", part, update.Timestamp, errs[part])
+				failed[part] = true
+				continue
+			}
+			gdelt.SaveProgress(stream, part, update.Timestamp, database_url)
+		}
+	}
+}
+
+// searchGKGWithRetries retries, because the latest GKG file is sometimes
+// listed before it can be downloaded
+func searchGKGWithRetries(update gdelt.Update, config Config) ([]GKGSource, error) {
+	articles, err := SearchGKG(update, config.GKG.Triggers)
+	for i := 0; i < 2 && err != nil; i++ {
+		log.Printf("GDELT.GKG error: %v", err)
+		log.Printf("trying again in 30s")
+		time.Sleep(30 * time.Second)
+		articles, err = SearchGKG(update, config.GKG.Triggers)
+	}
+	return articles, err
+}
+
+// processInterval runs the given parts of one 15 minute interval through
+// the pipeline, and returns the error of each part whose file couldn't be
+// read, so that the interval isn't marked as processed for it
+func processInterval(update gdelt.Update, parts []string, config Config, openai_key string, database_url string) map[string]error {
+	is_english := update.Stream == gdelt.English
+
+	// Mentions first, so that both batches can be ranked by coverage
+	var window gdelt.Window
+	if is_english {
+		var err error
+		window, err = UpdateCoverage(update, database_url)
+		if err != nil {
+			log.Printf("GDELT.Mentions error: %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	var gkg_err, events_err error
+	if slices.Contains(parts, gdelt.GKGPart) {
+		wg.Add(1)
 		go func() {
-			// The prospector can be processing more than 2 GKG 15 minute intervals at the same time!
+			defer wg.Done()
 			log.Println("Processing new gkg batch (this may take a min or two, as it's a large zip file)")
-			articles, err := SearchGKG()
+			articles, err := searchGKGWithRetries(update, config)
 			if err != nil {
-				for i := 0; i < 2; i++ {
-					log.Printf("GDELT.GKG error: %v", err)
-					if i != 9 {
-						log.Printf("trying again in 30s")
-					}
-					time.Sleep(30 * time.Second)
-					articles, err = SearchGKG()
-					if err == nil {
-						break
-					}
-				}
-				if err != nil {
-					log.Printf("GDELT.GKG error: %v", err)
-					log.Printf("Tried 10 times and couldn't parse GKG zip file")
-					return
-
-				}
+				gkg_err = err
+				log.Printf("GDELT.GKG error: %v", err)
+				log.Printf("Tried 3 times and couldn't parse GKG zip file")
+				return
 			}
 			log.Printf("Batch has %d articles\n", len(articles))
-			for i, article := range articles {
-				log.Printf("\n\nArticle #%v/%v [GDELT.GKG]: %v (%v)\n", i+1, len(articles), article.Title, article.Date)
+			ranked_articles := RankGKGSources(articles, window, database_url)
+			for i, article := range ranked_articles {
+				log.Printf("\n\nArticle #%v/%v [GDELT.GKG]: %v (%v, %d sources)\n", i+1, len(ranked_articles), article.Title, article.Date, article.Coverage.NumSources)
 
-				expanded_source, passes_filters := FilterAndExpandSource(article, openai_key, pg_database_url)
+				expanded_source, passes_filters := FilterAndExpandSource(article, openai_key, database_url)
 				if passes_filters {
 					SaveSource(expanded_source)
 				}
 			}
 			log.Printf("\n\nFinished processing gkg batch\n")
-			return
 		}()
 	}
+	if slices.Contains(parts, gdelt.EventsPart) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Println("Processing new events batch")
+			event_sources, err := SearchEvents(update, config.Events)
+			if err != nil {
+				events_err = err
+				log.Printf("GDELT.Events error: %v", err)
+				return
+			}
+			event_sources = RankEventSources(event_sources, window, database_url)
+			for i, event_source := range event_sources {
+				log.Printf("\n\nArticle #%v/%v [GDELT.Events]: %v (%v)\n", i+1, len(event_sources), event_source.Event.Describe(), event_source.Link)
+				expanded_source, passes_filters := FilterAndExpandEvent(event_source, openai_key, database_url)
+				if passes_filters {
+					SaveSource(expanded_source)
+				}
+			}
+			log.Printf("\n\nFinished processing events batch\n")
+		}()
+	}
+	wg.Wait()
 
-	// Keep main function alive
-	/*
-		done := make(chan bool)
-		log.Println("Main function is now waiting indefinitely...")
-		<-done
-	*/
-
+	return map[string]error{
+		gdelt.GKGPart:    gkg_err,
+		gdelt.EventsPart: events_err,
+	}
 }
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/gnews"
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"gopkg.in/yaml.v3"
+)
+
+// See config/gnews.yaml
+type QueryConfig struct {
+	Query  string   `yaml:"query"`
+	Tags   []string `yaml:"tags"`
+	Prompt string   `yaml:"prompt"`
+}
+
+type GNewsConfig struct {
+	Language  string        `yaml:"language"`
+	Region    string        `yaml:"region"`
+	When      string        `yaml:"when"`
+	Cadence   string        `yaml:"cadence"`
+	FreshDays int           `yaml:"fresh_days"`
+	Queries   []QueryConfig `yaml:"queries"`
+
+	cadence time.Duration
+}
+
+var whenPattern = regexp.MustCompile(`^[0-9]+[hdmy]$`)
+
+func (c GNewsConfig) Params() gnews.Params {
+	return gnews.Params{Language: c.Language, Region: c.Region, When: c.When}
+}
+
+// LoadConfig reads config/gnews.yaml, fills in defaults, and refuses to
+// start on a config we would only notice was broken hours later
+func LoadConfig(path string) (GNewsConfig, error) {
+	config := GNewsConfig{Language: "en-US", Region: "US", When: "1d", Cadence: "1h", FreshDays: 3}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return config, fmt.Errorf("Error parsing %v: %v", path, err)
+	}
+	if len(config.Queries) == 0 {
+		return config, errors.New("No queries in " + path)
+	}
+	if !strings.Contains(config.Language, "-") || config.Region == "" {
+		return config, fmt.Errorf("language should look like en-US, and region like US, got: %q and %q", config.Language, config.Region)
+	}
+	if config.When != "" && !whenPattern.MatchString(config.When) {
+		return config, fmt.Errorf("when should look like 1h or 7d, got: %q", config.When)
+	}
+	config.cadence, err = time.ParseDuration(config.Cadence)
+	if err != nil || config.cadence < 5*time.Minute {
+		return config, fmt.Errorf("Invalid cadence: %q", config.Cadence)
+	}
+
+	seen := map[string]bool{}
+	for i := range config.Queries {
+		query := &config.Queries[i]
+		query.Query = strings.TrimSpace(query.Query)
+		if query.Query == "" {
+			return config, fmt.Errorf("Query #%d is empty", i+1)
+		}
+		if seen[strings.ToLower(query.Query)] {
+			return config, fmt.Errorf("Query %q is listed twice", query.Query)
+		}
+		seen[strings.ToLower(query.Query)] = true
+		if query.Prompt == "" {
+			query.Prompt = "default"
+		}
+		if _, exists := llm.ImportanceChecks[query.Prompt]; !exists {
+			return config, fmt.Errorf("Query %q has an unknown prompt: %q", query.Query, query.Prompt)
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"log"
+
+	"git.nunosempere.com/NunoSempere/news/lib/feeds"
+	"git.nunosempere.com/NunoSempere/news/lib/feedstate"
+	"git.nunosempere.com/NunoSempere/news/lib/gnews"
+)
+
+// A search result, with its link unwrapped to the publisher's url
+type NewsItem struct {
+	feeds.Item
+	Query     QueryConfig
+	FreshDays int
+}
+
+// SearchGoogleNews returns the results we haven't processed in a previous
+// cycle. The caller saves the state once it has, see feedstate.Advance.
+// Results are tracked by id, rather than by the newest one seen, as a
+// search can turn up a result later than ones dated after it. Results
+// whose link couldn't be unwrapped are returned without one, so that the
+// caller counts them as failed.
+func SearchGoogleNews(query QueryConfig, config GNewsConfig, database_url string) ([]NewsItem, feedstate.FeedState, error) {
+	search_url := gnews.SearchURL(query.Query, config.Params())
+	body, state, unchanged, err := feedstate.Fetch(search_url, database_url)
+	if err != nil {
+		return nil, state, err
+	}
+	if unchanged {
+		return nil, state, nil
+	}
+
+	items, err := feeds.Parse(body)
+	if err != nil {
+		log.Printf("Error parsing search results for %q: %v", query.Query, err)
+		return nil, state, err
+	}
+
+	var item_ids []string
+	for _, item := range items {
+		item_ids = append(item_ids, item.ID)
+	}
+	state, new_results := feedstate.NewEntries(state, item_ids)
+	if len(new_results) == 0 {
+		log.Printf("Search unchanged: no results we haven't processed")
+	}
+	state.NumItems = len(item_ids)
+
+	var news_items []NewsItem
+	for _, item := range items {
+		if !new_results[item.ID] {
+			continue
+		}
+		publisher_url, err := gnews.PublisherURL(item.Link)
+		if err != nil {
+			log.Printf("Couldn't unwrap %v: %v", item.Link, err)
+			publisher_url = ""
+		}
+		item.Link = publisher_url
+		news_items = append(news_items, NewsItem{Item: item, Query: query, FreshDays: config.FreshDays})
+	}
+	return news_items, state, nil
+}
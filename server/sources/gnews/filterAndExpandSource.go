@@ -0,0 +1,20 @@
+package main
+
+import (
+	"git.nunosempere.com/NunoSempere/news/lib/pipeline"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+// FilterAndExpandSource puts a search result through the shared pipeline,
+// tagged with the query that found it
+func FilterAndExpandSource(item NewsItem, openai_key string, database_url string) (types.ExpandedSource, bool, error) {
+	return pipeline.FilterAndExpand(pipeline.Item{
+		Title:      item.Title,
+		Link:       item.Link,
+		Date:       item.Date,
+		DateMethod: "feed",
+		FreshDays:  item.FreshDays,
+		Prompt:     item.Query.Prompt,
+		Tags:       append([]string{"query:" + item.Query.Query}, item.Query.Tags...),
+	}, openai_key, database_url)
+}
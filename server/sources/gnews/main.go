@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/feedstate"
+	"git.nunosempere.com/NunoSempere/news/lib/pipeline"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+
+	logFile, err := os.OpenFile("sources/gnews/v2.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		log.Fatalf("error opening file: %v", err)
+	}
+	defer logFile.Close()
+	mw := io.MultiWriter(os.Stdout, logFile)
+	log.SetOutput(mw)
+
+	// Get keys
+	err = godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	openai_key := os.Getenv("OPENAI_KEY")
+	pg_database_url := os.Getenv("DATABASE_POOL_URL")
+
+	config, err := LoadConfig("config/gnews.yaml")
+	if err != nil {
+		log.Fatalf("Error loading gnews config: %v", err)
+	}
+
+	ticker := time.NewTicker(config.cadence)
+	defer ticker.Stop()
+	for ; true; <-ticker.C {
+		log.Println("(Re)starting Google News query loop")
+		for _, query := range config.Queries {
+			log.Printf("Query: %v", query.Query)
+			items, state, err := SearchGoogleNews(query, config, pg_database_url)
+			if err != nil {
+				log.Printf("Google News error: %v", err)
+				continue
+			}
+			log.Printf("Number of new results for query: %v", len(items))
+
+			// Results which couldn't be unwrapped, or which errored, aren't
+			// saved, and are retried next cycle, see feedstate.Advance
+			var item_ids []string
+			failed := map[string]bool{}
+			for i, item := range items {
+				log.Printf("\n\nResult #%v/%v [query \"%v\"]: %v (%v)\n", i+1, len(items), query.Query, item.Title, item.Link)
+				item_ids = append(item_ids, item.ID)
+				if item.Link == "" {
+					failed[item.ID] = true
+					continue
+				}
+				expanded_source, passes_filters, err := FilterAndExpandSource(item, openai_key, pg_database_url)
+				if err == nil && passes_filters {
+					err = pipeline.Save(expanded_source, pg_database_url)
+				}
+				if err != nil && err != pipeline.ErrAlreadySaved {
+					failed[item.ID] = true
+				}
+			}
+			feedstate.Save(feedstate.Advance(state, item_ids, failed), pg_database_url)
+		}
+		log.Printf("Finished Google News batch, pausing for %v", config.Cadence)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"gopkg.in/yaml.v3"
+)
+
+// See config/outbreaks.yaml
+type FeedConfig struct {
+	Name      string   `yaml:"name"`
+	Url       string   `yaml:"url"`
+	Format    string   `yaml:"format"`
+	Cadence   string   `yaml:"cadence"`
+	FreshDays int      `yaml:"fresh_days"`
+	Prompt    string   `yaml:"prompt"`
+	Tags      []string `yaml:"tags"`
+
+	cadence time.Duration
+}
+
+type OutbreaksConfig struct {
+	Feeds []FeedConfig `yaml:"feeds"`
+}
+
+// LoadConfig reads config/outbreaks.yaml, fills in defaults, and refuses to
+// start on a config we would only notice was broken hours later
+func LoadConfig(path string) (OutbreaksConfig, error) {
+	var config OutbreaksConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return config, fmt.Errorf("Error parsing %v: %v", path, err)
+	}
+	if len(config.Feeds) == 0 {
+		return config, errors.New("No feeds in " + path)
+	}
+	for i := range config.Feeds {
+		feed := &config.Feeds[i]
+		if feed.Name == "" || feed.Url == "" {
+			return config, fmt.Errorf("Feed #%d needs a name and a url", i+1)
+		}
+		if feed.Format == "" {
+			feed.Format = "rss"
+		}
+		if feed.Format != "rss" && feed.Format != "who-don" {
+			return config, fmt.Errorf("Feed %q has an unknown format: %q", feed.Name, feed.Format)
+		}
+		if feed.Cadence == "" {
+			feed.Cadence = "3h"
+		}
+		feed.cadence, err = time.ParseDuration(feed.Cadence)
+		if err != nil || feed.cadence < time.Minute {
+			return config, fmt.Errorf("Feed %q has an invalid cadence: %q", feed.Name, feed.Cadence)
+		}
+		if feed.Prompt == "" {
+			feed.Prompt = "bio"
+		}
+		if _, exists := llm.ImportanceChecks[feed.Prompt]; !exists {
+			return config, fmt.Errorf("Feed %q has an unknown prompt: %q", feed.Name, feed.Prompt)
+		}
+		if feed.FreshDays == 0 {
+			feed.FreshDays = 14
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/feeds"
+	"git.nunosempere.com/NunoSempere/news/lib/feedstate"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// A report from a feed, with the settings of the feed it came from
+type Report struct {
+	feeds.Item
+	Text string // the report itself, if the feed has it, so we needn't fetch the page
+	Feed FeedConfig
+}
+
+// The WHO's Disease Outbreak News API, which the DON pages are built from,
+// gives the whole report, in html sections
+type whoDONResponse struct {
+	Value []struct {
+		Id                     string `json:"Id"`
+		Title                  string `json:"Title"`
+		UrlName                string `json:"UrlName"`
+		PublicationDateAndTime string `json:"PublicationDateAndTime"`
+		Summary                string `json:"Summary"`
+		Epidemiology           string `json:"Epidemiology"`
+		Assessment             string `json:"Assessment"`
+	} `json:"value"`
+}
+
+func htmlToText(fragment string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(fragment))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(doc.Text())
+}
+
+func parseWHODON(body []byte) ([]feeds.Item, map[string]string, error) {
+	var response whoDONResponse
+	err := json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, nil, err
+	}
+	var items []feeds.Item
+	texts := map[string]string{}
+	for _, don := range response.Value {
+		item := feeds.Item{
+			ID:    don.Id,
+			Title: don.Title,
+			Link:  "https://www.who.int/emergencies/disease-outbreak-news/item/" + don.UrlName,
+		}
+		if date, err := time.Parse(time.RFC3339, don.PublicationDateAndTime); err == nil {
+			item.Date = date
+		}
+		var sections []string
+		for _, section := range []string{don.Summary, don.Epidemiology, don.Assessment} {
+			if text := htmlToText(section); text != "" {
+				sections = append(sections, text)
+			}
+		}
+		texts[item.ID] = strings.Join(sections, "\n\n")
+		items = append(items, item)
+	}
+	return items, texts, nil
+}
+
+// FetchFeed returns the reports we haven't processed in a previous poll.
+// The caller saves the feed state once it has, see feedstate.Advance.
+func FetchFeed(feed FeedConfig, database_url string) ([]Report, feedstate.FeedState, error) {
+	body, state, unchanged, err := feedstate.Fetch(feed.Url, database_url)
+	if err != nil {
+		return nil, state, err
+	}
+	if unchanged {
+		return nil, state, nil
+	}
+
+	var items []feeds.Item
+	texts := map[string]string{}
+	if feed.Format == "who-don" {
+		items, texts, err = parseWHODON(body)
+	} else {
+		items, err = feeds.Parse(body)
+	}
+	if err != nil {
+		log.Printf("Error parsing feed %v: %v", feed.Url, err)
+		return nil, state, err
+	}
+
+	var item_ids []string
+	for _, item := range items {
+		item_ids = append(item_ids, item.ID)
+	}
+	state, new_entries := feedstate.NewEntries(state, item_ids)
+	state.NumItems = len(item_ids)
+
+	var reports []Report
+	for _, item := range items {
+		if !new_entries[item.ID] || item.Link == "" {
+			continue
+		}
+		reports = append(reports, Report{Item: item, Text: texts[item.ID], Feed: feed})
+	}
+	return reports, state, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"git.nunosempere.com/NunoSempere/news/lib/pipeline"
+	"git.nunosempere.com/NunoSempere/news/lib/snapshots"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+func countString(n int) string {
+	if n < 0 {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// reportText is what the report says in full: its text if the feed had
+// it, or else the page we snapshotted, falling back on the summary
+func reportText(report Report, expanded_source types.ExpandedSource, database_url string) string {
+	if report.Text != "" {
+		return report.Text
+	}
+	if expanded_source.SnapshotID != 0 {
+		snapshot, err := snapshots.Load(expanded_source.SnapshotID, database_url)
+		if err == nil && snapshot.Text != "" {
+			return snapshot.Text
+		}
+	}
+	return expanded_source.Summary
+}
+
+// FilterAndExpandSource scores a report, and reads which outbreak it's
+// about, if it's about one. The first report about a pathogen in a
+// location passes whatever its importance, and is tagged as such.
+func FilterAndExpandSource(report Report, openai_key string, database_url string) (types.ExpandedSource, *Outbreak, bool, error) {
+	expanded_source, ok, err := pipeline.Expand(pipeline.Item{
+		Title:      report.Title,
+		Link:       report.Link,
+		Date:       report.Date,
+		DateMethod: "feed",
+		Text:       report.Text,
+		FreshDays:  report.Feed.FreshDays,
+		Tags:       append([]string{"bio", "outbreak"}, report.Feed.Tags...),
+	}, openai_key, database_url)
+	if !ok || err != nil {
+		return expanded_source, nil, false, err
+	}
+
+	// Reports which aren't about one outbreak, e.g. a weekly round-up, are
+	// still scored, just without the pathogen/location flag. Any other
+	// error is retried, so as not to lose the flag.
+	var outbreak *Outbreak
+	text := reportText(report, expanded_source, database_url)
+	outbreak_box, err := llm.ExtractOutbreak(expanded_source.Title+"\n\n"+text, openai_key)
+	if err != nil && !errors.Is(err, llm.ErrNotAnOutbreak) {
+		log.Printf("Outbreak extraction failed for %s: %v", report.Link, err)
+		return expanded_source, nil, false, err
+	}
+	if err == nil && outbreak_box.Pathogen != "" && outbreak_box.Location != "" {
+		is_new, err := IsNewOutbreakPair(outbreak_box.Pathogen, outbreak_box.Location, database_url)
+		if err != nil {
+			return expanded_source, nil, false, err
+		}
+		outbreak = &Outbreak{OutbreakBox: *outbreak_box, IsNewPair: is_new}
+
+		header := fmt.Sprintf("Pathogen: %s. Location: %s. Cases: %s. Deaths: %s.", outbreak.Pathogen, outbreak.Location, countString(outbreak.Cases), countString(outbreak.Deaths))
+		expanded_source.Tags = append(expanded_source.Tags, "pathogen:"+normalize(outbreak.Pathogen), "location:"+normalize(outbreak.Location))
+		if outbreak.IsNewPair {
+			header = "[First report of " + outbreak.Pathogen + " in " + outbreak.Location + "] " + header
+			expanded_source.Tags = append(expanded_source.Tags, "new-pathogen-location")
+		}
+		expanded_source.Summary = header + "\n\n" + expanded_source.Summary
+		log.Printf("Summary: %s", expanded_source.Summary)
+	} else {
+		log.Printf("No single outbreak in %s: %v", report.Link, err)
+	}
+
+	err = pipeline.CheckImportance(&expanded_source, report.Feed.Prompt, openai_key)
+	if err != nil {
+		return expanded_source, nil, false, err
+	}
+	is_new_pair := outbreak != nil && outbreak.IsNewPair
+	return expanded_source, outbreak, expanded_source.ImportanceBool || is_new_pair, nil
+}
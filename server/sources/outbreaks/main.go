@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/feedstate"
+	"git.nunosempere.com/NunoSempere/news/lib/pipeline"
+	"github.com/joho/godotenv"
+)
+
+func pollFeed(feed FeedConfig, openai_key string, pg_database_url string) {
+	ticker := time.NewTicker(feed.cadence)
+	defer ticker.Stop()
+	for ; true; <-ticker.C {
+		reports, state, err := FetchFeed(feed, pg_database_url)
+		if err != nil {
+			log.Printf("Error fetching feed %v: %v", feed.Name, err)
+			continue
+		}
+		log.Printf("Feed %v has %d new reports", feed.Name, len(reports))
+
+		// Reports which errored aren't saved, and are retried next poll, see
+		// feedstate.Advance
+		var report_ids []string
+		failed := map[string]bool{}
+		for i, report := range reports {
+			log.Printf("\n\nReport #%v/%v [%v]: %v (%v)\n", i+1, len(reports), feed.Name, report.Title, report.Link)
+			report_ids = append(report_ids, report.ID)
+			expanded_source, outbreak, passes_filters, err := FilterAndExpandSource(report, openai_key, pg_database_url)
+			if err == nil && passes_filters {
+				err = SaveReport(expanded_source, outbreak, pg_database_url)
+			}
+			if err != nil && err != pipeline.ErrAlreadySaved {
+				failed[report.ID] = true
+			}
+		}
+		feedstate.Save(feedstate.Advance(state, report_ids, failed), pg_database_url)
+	}
+}
+
+func main() {
+
+	logFile, err := os.OpenFile("sources/outbreaks/v2.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		log.Fatalf("error opening file: %v", err)
+	}
+	defer logFile.Close()
+	mw := io.MultiWriter(os.Stdout, logFile)
+	log.SetOutput(mw)
+
+	// Get keys
+	err = godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	openai_key := os.Getenv("OPENAI_KEY")
+	pg_database_url := os.Getenv("DATABASE_POOL_URL")
+
+	config, err := LoadConfig("config/outbreaks.yaml")
+	if err != nil {
+		log.Fatalf("Error loading outbreaks config: %v", err)
+	}
+
+	// Each feed is polled on its own cadence
+	for _, feed := range config.Feeds {
+		log.Printf("Polling %v every %v", feed.Name, feed.Cadence)
+		go pollFeed(feed, openai_key, pg_database_url)
+	}
+	select {}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"git.nunosempere.com/NunoSempere/news/lib/pipeline"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+	"github.com/jackc/pgx/v5"
+)
+
+// What we read off a report about one outbreak
+type Outbreak struct {
+	llm.OutbreakBox
+	IsNewPair bool // no report about this pathogen in this location saved before
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// IsNewOutbreakPair says whether we've yet to save a report about pathogen
+// in location
+func IsNewOutbreakPair(pathogen string, location string, database_url string) (bool, error) {
+	conn, err := pgx.Connect(context.Background(), database_url)
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return false, err
+	}
+	defer conn.Close(context.Background())
+
+	var is_new bool
+	err = conn.QueryRow(context.Background(), `
+        SELECT NOT EXISTS (SELECT 1 FROM outbreak_pairs WHERE pathogen = $1 AND location = $2)
+    `, normalize(pathogen), normalize(location)).Scan(&is_new)
+	if err != nil {
+		log.Printf("Error looking up outbreak pair: %v\n", err)
+		return false, err
+	}
+	return is_new, nil
+}
+
+// nullIfUnknown turns the -1 which llm.ExtractOutbreak uses for a count
+// the report didn't give into NULL
+func nullIfUnknown(n int) *int {
+	if n < 0 {
+		return nil
+	}
+	return &n
+}
+
+// SaveReport saves the report, and if it's about one outbreak, notes it
+// both in its pathogen/location pair and with its own counts. Both are
+// written in one transaction, so that a report which wasn't saved doesn't
+// use up the pair's first-report flag, and one which was always has its
+// pair recorded.
+func SaveReport(expanded_source types.ExpandedSource, outbreak *Outbreak, database_url string) error {
+	return pipeline.InTransaction(database_url, func(tx pgx.Tx) error {
+		err := pipeline.InsertSource(tx, expanded_source)
+		if err != nil || outbreak == nil {
+			return err
+		}
+		return recordOutbreak(tx, *outbreak, expanded_source.Link)
+	})
+}
+
+func recordOutbreak(tx pgx.Tx, outbreak Outbreak, link string) error {
+	pathogen, location := normalize(outbreak.Pathogen), normalize(outbreak.Location)
+	_, err := tx.Exec(context.Background(), `
+        INSERT INTO outbreak_pairs (pathogen, location, first_link)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (pathogen, location) DO UPDATE SET
+            last_seen_at = CURRENT_TIMESTAMP,
+            num_reports = outbreak_pairs.num_reports + 1
+    `, pathogen, location, link)
+	if err != nil {
+		log.Printf("Error recording outbreak pair: %v\n", err)
+		return err
+	}
+	_, err = tx.Exec(context.Background(), `
+        INSERT INTO outbreak_reports (link, pathogen, location, num_cases, num_deaths, is_new_pair)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (link) DO NOTHING
+    `, link, pathogen, location, nullIfUnknown(outbreak.Cases), nullIfUnknown(outbreak.Deaths), outbreak.IsNewPair)
+	if err != nil {
+		log.Printf("Error recording outbreak report: %v\n", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"git.nunosempere.com/NunoSempere/news/lib/scraper"
+	"gopkg.in/yaml.v3"
+)
+
+// See config/scrapers.yaml
+type ScraperConfig struct {
+	scraper.Definition `yaml:",inline"`
+
+	Cadence   string   `yaml:"cadence"`
+	Prompt    string   `yaml:"prompt"`
+	FreshDays int      `yaml:"fresh_days"`
+	Tags      []string `yaml:"tags"`
+
+	cadence time.Duration
+}
+
+type ScrapersConfig struct {
+	Scrapers []ScraperConfig `yaml:"scrapers"`
+}
+
+// LoadConfig reads config/scrapers.yaml, fills in defaults, and refuses to
+// start on a config we would only notice was broken hours later
+func LoadConfig(path string) (ScrapersConfig, error) {
+	var config ScrapersConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return config, fmt.Errorf("Error parsing %v: %v", path, err)
+	}
+	if len(config.Scrapers) == 0 {
+		return config, errors.New("No scrapers in " + path)
+	}
+	seen := map[string]bool{}
+	for i := range config.Scrapers {
+		s := &config.Scrapers[i]
+		err = s.Validate()
+		if err != nil {
+			return config, err
+		}
+		if seen[s.Name] {
+			return config, fmt.Errorf("Scraper %q is listed twice", s.Name)
+		}
+		seen[s.Name] = true
+		if s.Cadence == "" {
+			s.Cadence = "6h"
+		}
+		s.cadence, err = time.ParseDuration(s.Cadence)
+		if err != nil || s.cadence < 5*time.Minute {
+			return config, fmt.Errorf("Scraper %q has an invalid cadence: %q", s.Name, s.Cadence)
+		}
+		if s.Prompt == "" {
+			s.Prompt = "default"
+		}
+		if _, exists := llm.ImportanceChecks[s.Prompt]; !exists {
+			return config, fmt.Errorf("Scraper %q has an unknown prompt: %q", s.Name, s.Prompt)
+		}
+		if s.FreshDays == 0 {
+			s.FreshDays = 15
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/feedstate"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+// An item from a scraped list page, with the settings of its scraper
+type ScrapedItem struct {
+	types.Source
+	Scraper ScraperConfig
+}
+
+// FetchScraper returns the items we haven't processed in a previous run,
+// going by the links on the page which feed_state has as done. The caller
+// saves the state, see feedstate.Advance.
+func FetchScraper(s ScraperConfig, database_url string) ([]ScrapedItem, feedstate.FeedState, error) {
+	sources, err := s.Scrape()
+	if err != nil && len(sources) == 0 {
+		return nil, feedstate.FeedState{}, err
+	}
+
+	state, err := feedstate.Load(s.URL, database_url)
+	if err != nil {
+		state.Url = s.URL
+	}
+	var links []string
+	for _, source := range sources {
+		links = append(links, source.Link)
+	}
+	state, new_links := feedstate.NewEntries(state, links)
+	if len(new_links) == 0 {
+		log.Printf("Page unchanged: no items we haven't processed")
+	}
+	state.LastSuccessAt = time.Now()
+	state.NumItems = len(links)
+
+	var items []ScrapedItem
+	for _, source := range sources {
+		if !new_links[source.Link] {
+			continue
+		}
+		items = append(items, ScrapedItem{Source: source, Scraper: s})
+	}
+	return items, state, nil
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/pipeline"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+// FilterAndExpandSource puts a scraped item through the shared pipeline.
+// Scrapers without a date selector leave the date to be read off the page.
+func FilterAndExpandSource(item ScrapedItem, openai_key string, database_url string) (types.ExpandedSource, bool, error) {
+	item_date, _ := time.Parse(time.RFC3339, item.Date)
+	return pipeline.FilterAndExpand(pipeline.Item{
+		Title:      item.Title,
+		Link:       item.Link,
+		Date:       item_date,
+		DateMethod: "list page",
+		FreshDays:  item.Scraper.FreshDays,
+		Prompt:     item.Scraper.Prompt,
+		Tags:       item.Scraper.Tags,
+	}, openai_key, database_url)
+}
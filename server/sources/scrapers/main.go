@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/feedstate"
+	"git.nunosempere.com/NunoSempere/news/lib/pipeline"
+	"github.com/joho/godotenv"
+)
+
+func pollScraper(s ScraperConfig, openai_key string, pg_database_url string) {
+	ticker := time.NewTicker(s.cadence)
+	defer ticker.Stop()
+	for ; true; <-ticker.C {
+		items, state, err := FetchScraper(s, pg_database_url)
+		if err != nil {
+			log.Printf("Error scraping %v: %v", s.Name, err)
+			continue
+		}
+		log.Printf("%v has %d new items", s.Name, len(items))
+
+		// Items which errored aren't saved, and are retried next run, see
+		// feedstate.Advance
+		var links []string
+		failed := map[string]bool{}
+		for i, item := range items {
+			log.Printf("\n\nItem #%v/%v [%v]: %v (%v)\n", i+1, len(items), s.Name, item.Title, item.Link)
+			links = append(links, item.Link)
+			expanded_source, passes_filters, err := FilterAndExpandSource(item, openai_key, pg_database_url)
+			if err == nil && passes_filters {
+				err = pipeline.Save(expanded_source, pg_database_url)
+			}
+			if err != nil && err != pipeline.ErrAlreadySaved {
+				failed[item.Link] = true
+			}
+		}
+		feedstate.Save(feedstate.Advance(state, links, failed), pg_database_url)
+	}
+}
+
+func main() {
+
+	logFile, err := os.OpenFile("sources/scrapers/v2.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		log.Fatalf("error opening file: %v", err)
+	}
+	defer logFile.Close()
+	mw := io.MultiWriter(os.Stdout, logFile)
+	log.SetOutput(mw)
+
+	// Get keys
+	err = godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	openai_key := os.Getenv("OPENAI_KEY")
+	pg_database_url := os.Getenv("DATABASE_POOL_URL")
+
+	config, err := LoadConfig("config/scrapers.yaml")
+	if err != nil {
+		log.Fatalf("Error loading scrapers config: %v", err)
+	}
+
+	// Each site is scraped on its own cadence
+	for _, s := range config.Scrapers {
+		log.Printf("Scraping %v every %v", s.Name, s.Cadence)
+		go pollScraper(s, openai_key, pg_database_url)
+	}
+	select {}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"git.nunosempere.com/NunoSempere/news/lib/social"
+	"gopkg.in/yaml.v3"
+)
+
+// See config/social.yaml
+type GroupConfig struct {
+	Name    string   `yaml:"name"`
+	Tags    []string `yaml:"tags"`
+	Prompt  string   `yaml:"prompt"`
+	Bluesky struct {
+		Accounts []string `yaml:"accounts"`
+		Lists    []string `yaml:"lists"`
+		Hashtags []string `yaml:"hashtags"`
+	} `yaml:"bluesky"`
+	Mastodon struct {
+		Accounts []string `yaml:"accounts"`
+		Hashtags []string `yaml:"hashtags"`
+	} `yaml:"mastodon"`
+}
+
+type SocialConfig struct {
+	Cadence   string        `yaml:"cadence"`
+	FreshDays int           `yaml:"fresh_days"`
+	Groups    []GroupConfig `yaml:"groups"`
+
+	cadence time.Duration
+}
+
+// One account, list or hashtag to poll
+type Feed struct {
+	Kind   string // e.g. "bluesky account"
+	Target string // e.g. the handle
+	Group  GroupConfig
+	fetch  func(target string) ([]social.Post, error)
+}
+
+// The key its last seen post is kept under in feed_state
+func (f Feed) Key() string {
+	return "social:" + f.Kind + ":" + f.Target
+}
+
+func (f Feed) Fetch() ([]social.Post, error) {
+	return f.fetch(f.Target)
+}
+
+// Feeds lists everything to poll, and checks that each target is well formed
+func (c SocialConfig) Feeds() ([]Feed, error) {
+	var feeds []Feed
+	for _, group := range c.Groups {
+		add := func(kind string, targets []string, fetch func(string) ([]social.Post, error)) {
+			for _, target := range targets {
+				feeds = append(feeds, Feed{Kind: kind, Target: target, Group: group, fetch: fetch})
+			}
+		}
+		add("bluesky account", group.Bluesky.Accounts, social.BlueskyAccount)
+		add("bluesky list", group.Bluesky.Lists, social.BlueskyList)
+		add("bluesky hashtag", group.Bluesky.Hashtags, social.BlueskyHashtag)
+		add("mastodon account", group.Mastodon.Accounts, social.MastodonAccount)
+		add("mastodon hashtag", group.Mastodon.Hashtags, social.MastodonHashtag)
+	}
+	seen := map[string]bool{}
+	for _, feed := range feeds {
+		var err error
+		switch feed.Kind {
+		case "bluesky list":
+			_, err = social.BlueskyListURI(feed.Target)
+		case "mastodon account", "mastodon hashtag":
+			_, _, err = social.SplitAcct(feed.Target)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Group %q: %v", feed.Group.Name, err)
+		}
+		if seen[feed.Key()] {
+			return nil, fmt.Errorf("The %v %q is listed twice", feed.Kind, feed.Target)
+		}
+		seen[feed.Key()] = true
+	}
+	return feeds, nil
+}
+
+// LoadConfig reads config/social.yaml, fills in defaults, and refuses to
+// start on a config we would only notice was broken hours later
+func LoadConfig(path string) (SocialConfig, error) {
+	config := SocialConfig{Cadence: "30m", FreshDays: 3}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return config, fmt.Errorf("Error parsing %v: %v", path, err)
+	}
+	config.cadence, err = time.ParseDuration(config.Cadence)
+	if err != nil || config.cadence < 5*time.Minute {
+		return config, fmt.Errorf("Invalid cadence: %q", config.Cadence)
+	}
+	if len(config.Groups) == 0 {
+		return config, errors.New("No groups in " + path)
+	}
+	for i := range config.Groups {
+		group := &config.Groups[i]
+		if group.Name == "" {
+			return config, fmt.Errorf("Group #%d needs a name", i+1)
+		}
+		if group.Prompt == "" {
+			group.Prompt = "default"
+		}
+		if _, exists := llm.ImportanceChecks[group.Prompt]; !exists {
+			return config, fmt.Errorf("Group %q has an unknown prompt: %q", group.Name, group.Prompt)
+		}
+	}
+	_, err = config.Feeds()
+	return config, err
+}
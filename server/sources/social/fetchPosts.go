@@ -0,0 +1,48 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/feedstate"
+	"git.nunosempere.com/NunoSempere/news/lib/social"
+)
+
+// A post, with the settings of the group it came from
+type PostItem struct {
+	social.Post
+	Group GroupConfig
+}
+
+// FetchPosts returns the posts we haven't processed in a previous poll. The
+// caller saves the state once it has, see feedstate.Advance.
+func FetchPosts(feed Feed, database_url string) ([]PostItem, feedstate.FeedState, error) {
+	posts, err := feed.Fetch()
+	if err != nil {
+		return nil, feedstate.FeedState{}, err
+	}
+
+	state, err := feedstate.Load(feed.Key(), database_url)
+	if err != nil {
+		state.Url = feed.Key()
+	}
+	var post_urls []string
+	for _, post := range posts {
+		post_urls = append(post_urls, post.URL)
+	}
+	state, new_posts := feedstate.NewEntries(state, post_urls)
+	state.LastSuccessAt = time.Now()
+	state.NumItems = len(post_urls)
+	if len(new_posts) > 0 {
+		log.Printf("The %v %v has %d new posts", feed.Kind, feed.Target, len(new_posts))
+	}
+
+	var items []PostItem
+	for _, post := range posts {
+		if !new_posts[post.URL] {
+			continue
+		}
+		items = append(items, PostItem{Post: post, Group: feed.Group})
+	}
+	return items, state, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/filters"
+	"git.nunosempere.com/NunoSempere/news/lib/pipeline"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+// FilterAndExpandPost makes the post itself a candidate, with its text as
+// the summary, since it's often the first report of something
+func FilterAndExpandPost(item PostItem, fresh_days int, openai_key string, database_url string) (types.ExpandedSource, bool, error) {
+	expanded_source := types.ExpandedSource{
+		Title:      item.Title(),
+		Link:       item.URL,
+		Date:       item.CreatedAt.Format(time.RFC3339),
+		DateMethod: item.Network,
+		Summary:    item.Text,
+		Tags:       append([]string{item.Network, "social-post", "group:" + item.Group.Name}, item.Group.Tags...),
+	}
+
+	is_dupe := filters.IsDupe(types.Source{Title: expanded_source.Title, Link: item.URL}, database_url)
+	if is_dupe {
+		return expanded_source, false, nil
+	}
+	if !pipeline.IsFresh(item.CreatedAt, fresh_days) {
+		log.Printf("Post is not fresh")
+		return expanded_source, false, nil
+	}
+	err := pipeline.CheckImportance(&expanded_source, item.Group.Prompt, openai_key)
+	if err != nil {
+		return expanded_source, false, err
+	}
+	return expanded_source, expanded_source.ImportanceBool, nil
+}
+
+// FilterAndExpandLink puts an article linked from a post through the same
+// pipeline as feed items, titled with the page's own title
+func FilterAndExpandLink(link string, item PostItem, fresh_days int, openai_key string, database_url string) (types.ExpandedSource, bool, error) {
+	expanded_source, ok, err := pipeline.Expand(pipeline.Item{
+		Link:      link,
+		FreshDays: fresh_days,
+		Tags:      append([]string{item.Network, "social-link", "group:" + item.Group.Name}, item.Group.Tags...),
+	}, openai_key, database_url)
+	if !ok || err != nil {
+		return expanded_source, false, err
+	}
+
+	// Why it was posted can matter as much as what it says
+	expanded_source.Summary += "\n\nShared by @" + item.Author + ": " + item.Text
+	err = pipeline.CheckImportance(&expanded_source, item.Group.Prompt, openai_key)
+	if err != nil {
+		return expanded_source, false, err
+	}
+	return expanded_source, expanded_source.ImportanceBool, nil
+}
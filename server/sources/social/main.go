@@ -0,0 +1,79 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/feedstate"
+	"git.nunosempere.com/NunoSempere/news/lib/pipeline"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+	"github.com/joho/godotenv"
+)
+
+func processFeed(feed Feed, config SocialConfig, openai_key string, pg_database_url string) {
+	items, state, err := FetchPosts(feed, pg_database_url)
+	if err != nil {
+		log.Printf("Error fetching the %v %v: %v", feed.Kind, feed.Target, err)
+		return
+	}
+
+	// Posts for which the post or one of its links errored aren't all
+	// saved, and are retried next poll, see feedstate.Advance
+	var post_urls []string
+	failed := map[string]bool{}
+	keep := func(expanded_source types.ExpandedSource, passes_filters bool, err error, post_url string) {
+		if err == nil && passes_filters {
+			err = pipeline.Save(expanded_source, pg_database_url)
+		}
+		if err != nil && err != pipeline.ErrAlreadySaved {
+			failed[post_url] = true
+		}
+	}
+	for i, item := range items {
+		log.Printf("\n\nPost #%v/%v [%v]: %v (%v)\n", i+1, len(items), feed.Target, item.Title(), item.URL)
+		post_urls = append(post_urls, item.URL)
+		for _, link := range item.Links {
+			expanded_source, passes_filters, err := FilterAndExpandLink(link, item, config.FreshDays, openai_key, pg_database_url)
+			keep(expanded_source, passes_filters, err, item.URL)
+		}
+		expanded_source, passes_filters, err := FilterAndExpandPost(item, config.FreshDays, openai_key, pg_database_url)
+		keep(expanded_source, passes_filters, err, item.URL)
+	}
+	feedstate.Save(feedstate.Advance(state, post_urls, failed), pg_database_url)
+}
+
+func main() {
+
+	logFile, err := os.OpenFile("sources/social/v2.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		log.Fatalf("error opening file: %v", err)
+	}
+	defer logFile.Close()
+	mw := io.MultiWriter(os.Stdout, logFile)
+	log.SetOutput(mw)
+
+	// Get keys
+	err = godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	openai_key := os.Getenv("OPENAI_KEY")
+	pg_database_url := os.Getenv("DATABASE_POOL_URL")
+
+	config, err := LoadConfig("config/social.yaml")
+	if err != nil {
+		log.Fatalf("Error loading social config: %v", err)
+	}
+	feeds, _ := config.Feeds()
+	log.Printf("Polling %d accounts, lists and hashtags every %v", len(feeds), config.Cadence)
+
+	ticker := time.NewTicker(config.cadence)
+	defer ticker.Stop()
+	for ; true; <-ticker.C {
+		for _, feed := range feeds {
+			processFeed(feed, config, openai_key, pg_database_url)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/swpc"
+	"gopkg.in/yaml.v3"
+)
+
+// See config/spaceweather.yaml
+type SpaceWeatherConfig struct {
+	Cadence    string `yaml:"cadence"`
+	EpisodeGap string `yaml:"episode_gap"`
+	Thresholds struct {
+		Geomagnetic   string `yaml:"geomagnetic"`
+		RadioBlackout string `yaml:"radio_blackout"`
+		Radiation     string `yaml:"radiation"`
+	} `yaml:"thresholds"`
+	AlertPatterns []string `yaml:"alert_patterns"`
+	Tags          []string `yaml:"tags"`
+
+	cadence        time.Duration
+	episode_gap    time.Duration
+	thresholds     map[string]swpc.Level // by scale
+	alert_patterns []*regexp.Regexp
+}
+
+// LoadConfig reads config/spaceweather.yaml, fills in defaults, and refuses
+// to start on a config we would only notice was broken hours later
+func LoadConfig(path string) (SpaceWeatherConfig, error) {
+	config := SpaceWeatherConfig{Cadence: "15m", EpisodeGap: "24h"}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return config, fmt.Errorf("Error parsing %v: %v", path, err)
+	}
+	config.cadence, err = time.ParseDuration(config.Cadence)
+	if err != nil || config.cadence < time.Minute {
+		return config, fmt.Errorf("Invalid cadence: %q", config.Cadence)
+	}
+	config.episode_gap, err = time.ParseDuration(config.EpisodeGap)
+	if err != nil || config.episode_gap < config.cadence {
+		return config, fmt.Errorf("Invalid episode_gap: %q", config.EpisodeGap)
+	}
+
+	config.thresholds = map[string]swpc.Level{}
+	for scale, threshold := range map[string]string{"G": config.Thresholds.Geomagnetic, "R": config.Thresholds.RadioBlackout, "S": config.Thresholds.Radiation} {
+		if threshold == "" {
+			continue // no items from levels on this scale, only from alert_patterns
+		}
+		level, err := swpc.ParseLevel(threshold)
+		if err != nil || level.Scale != scale {
+			return config, fmt.Errorf("Invalid threshold for the %v scale: %q", scale, threshold)
+		}
+		config.thresholds[scale] = level
+	}
+	for _, pattern := range config.AlertPatterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return config, fmt.Errorf("Invalid alert pattern %q: %v", pattern, err)
+		}
+		config.alert_patterns = append(config.alert_patterns, compiled)
+	}
+	return config, nil
+}
+
+// Crosses is true if level is at or above the threshold for its scale
+func (c SpaceWeatherConfig) Crosses(level swpc.Level) bool {
+	threshold, exists := c.thresholds[level.Scale]
+	return exists && level.Value >= threshold.Value
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/pipeline"
+	"git.nunosempere.com/NunoSempere/news/lib/swpc"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+	"github.com/jackc/pgx/v5"
+)
+
+var scaleNames = map[string]string{
+	"G": "Geomagnetic storm",
+	"R": "Radio blackout",
+	"S": "Solar radiation storm",
+}
+
+var scalePages = map[string]string{
+	"G": "https://www.swpc.noaa.gov/phenomena/geomagnetic-storms",
+	"R": "https://www.swpc.noaa.gov/phenomena/solar-flares-radio-blackouts",
+	"S": "https://www.swpc.noaa.gov/phenomena/solar-radiation-storm",
+}
+
+func eventTitle(scale string, peak_level int) string {
+	if peak_level == 0 {
+		return scaleNames[scale] + " watch"
+	}
+	level := swpc.Level{Scale: scale, Value: peak_level}
+	return fmt.Sprintf("%s reaching %s (%s)", scaleNames[scale], level, level.Name())
+}
+
+func eventSummary(timeline string) string {
+	return "From NOAA's Space Weather Prediction Center, updated as the event evolves:\n\n" + timeline
+}
+
+func addToTimeline(timeline string, note string) string {
+	lines := strings.Split(timeline, "\n")
+	if timeline == "" {
+		lines = nil
+	}
+	lines = append(lines, note)
+	sort.Strings(lines) // notes start with their time
+	return strings.Join(lines, "\n")
+}
+
+// RecordObservation adds an observation to the ongoing event on its
+// scale, or starts a new one, and keeps the event's item in sources up to
+// date. The event and its item are saved together, or not at all.
+func RecordObservation(observation Observation, config SpaceWeatherConfig) error {
+	return pipeline.InTransaction(os.Getenv("DATABASE_POOL_URL"), func(tx pgx.Tx) error {
+		return recordObservation(tx, observation, config)
+	})
+}
+
+func recordObservation(tx pgx.Tx, observation Observation, config SpaceWeatherConfig) error {
+	ctx := context.Background()
+	var id int
+	var link, timeline string
+	var peak_level int
+	var last_seen_at time.Time
+	err := tx.QueryRow(ctx, `
+        SELECT id, link, timeline, peak_level, last_seen_at FROM space_weather_events
+        WHERE scale = $1 AND last_seen_at >= $2 AND started_at <= $3
+        ORDER BY last_seen_at DESC LIMIT 1
+    `, observation.Scale, observation.Time.Add(-config.episode_gap), observation.Time.Add(config.episode_gap)).Scan(&id, &link, &timeline, &peak_level, &last_seen_at)
+
+	if err == pgx.ErrNoRows {
+		// Old observations, e.g. on the first run, don't start events
+		if time.Since(observation.Time) > config.episode_gap {
+			return nil
+		}
+		link = scalePages[observation.Scale] + "#" + observation.Time.UTC().Format("20060102T1504Z")
+		timeline = observation.Note
+		_, err = tx.Exec(ctx, `
+            INSERT INTO space_weather_events (scale, link, started_at, last_seen_at, peak_level, timeline)
+            VALUES ($1, $2, $3, $3, $4, $5)
+        `, observation.Scale, link, observation.Time, observation.Level.Value, timeline)
+		if err != nil {
+			log.Printf("Error saving space weather event: %v\n", err)
+			return err
+		}
+		err = pipeline.InsertSource(tx, types.ExpandedSource{
+			Title:               eventTitle(observation.Scale, observation.Level.Value),
+			Link:                link,
+			Date:                observation.Time.Format(time.RFC3339),
+			Summary:             eventSummary(timeline),
+			ImportanceBool:      true,
+			ImportanceReasoning: "Crossed a threshold in config/spaceweather.yaml: " + observation.Note,
+			DateMethod:          "swpc",
+			Tags:                append([]string{"swpc", "scale:" + observation.Scale}, config.Tags...),
+		})
+		if err != nil && err != pipeline.ErrAlreadySaved {
+			return err
+		}
+		log.Printf("New space weather event: %v", observation.Note)
+		return nil
+	}
+	if err != nil {
+		log.Printf("Error loading space weather events: %v\n", err)
+		return err
+	}
+
+	if strings.Contains(timeline, observation.Note) {
+		return nil
+	}
+	timeline = addToTimeline(timeline, observation.Note)
+	worse := observation.Level.Value > peak_level
+	peak_level = max(peak_level, observation.Level.Value)
+	if observation.Time.After(last_seen_at) {
+		last_seen_at = observation.Time
+	}
+	_, err = tx.Exec(ctx, `
+        UPDATE space_weather_events SET timeline = $1, peak_level = $2, last_seen_at = $3 WHERE id = $4
+    `, timeline, peak_level, last_seen_at, id)
+	if err != nil {
+		log.Printf("Error updating space weather event: %v\n", err)
+		return err
+	}
+	err = pipeline.UpdateSource(tx, pipeline.Revision{
+		Link:      link,
+		Title:     eventTitle(observation.Scale, peak_level),
+		Summary:   eventSummary(timeline),
+		Worse:     worse,
+		Reasoning: "Got worse: " + observation.Note,
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Updated space weather event %v: %v", link, observation.Note)
+	return nil
+}
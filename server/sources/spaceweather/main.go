@@ -0,0 +1,42 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+
+	logFile, err := os.OpenFile("sources/spaceweather/v2.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		log.Fatalf("error opening file: %v", err)
+	}
+	defer logFile.Close()
+	mw := io.MultiWriter(os.Stdout, logFile)
+	log.SetOutput(mw)
+
+	// Get keys. No OpenAI key: items come from thresholds, not an LLM
+	err = godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+
+	config, err := LoadConfig("config/spaceweather.yaml")
+	if err != nil {
+		log.Fatalf("Error loading space weather config: %v", err)
+	}
+
+	ticker := time.NewTicker(config.cadence)
+	defer ticker.Stop()
+	for ; true; <-ticker.C {
+		observations := CollectObservations(config)
+		log.Printf("%d observations above thresholds", len(observations))
+		for _, observation := range observations {
+			RecordObservation(observation, config)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/swpc"
+)
+
+// Something that crossed a threshold. The same observation comes back
+// every cycle while it's in SWPC's products, so Note, which starts with
+// its time, is what we dedupe on.
+type Observation struct {
+	Scale string
+	Time  time.Time
+	Level swpc.Level // 0 for watches and warnings
+	Note  string
+}
+
+const noteTimeLayout = "2006-01-02 15:04 UTC"
+
+func kpObservations(readings []swpc.KpReading, config SpaceWeatherConfig) []Observation {
+	var observations []Observation
+	for _, reading := range readings {
+		level := swpc.KpToG(reading.Kp)
+		if !config.Crosses(level) {
+			continue
+		}
+		note := fmt.Sprintf("%s: planetary K-index of %.2f, %s (%s)", reading.Time.Format(noteTimeLayout), reading.Kp, level, level.Name())
+		observations = append(observations, Observation{Scale: "G", Time: reading.Time, Level: level, Note: note})
+	}
+	return observations
+}
+
+// xrayObservations gives one observation per flare, at its peak, rather
+// than one per minute above the threshold
+func xrayObservations(readings []swpc.XrayReading, config SpaceWeatherConfig) []Observation {
+	var observations []Observation
+	var peak *swpc.XrayReading
+	flush := func() {
+		if peak == nil {
+			return
+		}
+		level := swpc.FluxToR(peak.Flux)
+		note := fmt.Sprintf("%s: %s flare peaking, %s (%s) radio blackout", peak.Time.Format(noteTimeLayout), swpc.FlareClass(peak.Flux), level, level.Name())
+		observations = append(observations, Observation{Scale: "R", Time: peak.Time, Level: level, Note: note})
+		peak = nil
+	}
+	sort.Slice(readings, func(i, j int) bool { return readings[i].Time.Before(readings[j].Time) })
+	for i := range readings {
+		if !config.Crosses(swpc.FluxToR(readings[i].Flux)) {
+			flush()
+			continue
+		}
+		if peak == nil || readings[i].Flux > peak.Flux {
+			peak = &readings[i]
+		}
+	}
+	// A flare still above the threshold is noted at its peak so far, and
+	// again when it peaks higher
+	flush()
+	return observations
+}
+
+func alertObservations(alerts []swpc.Alert, config SpaceWeatherConfig) []Observation {
+	var observations []Observation
+	for _, alert := range alerts {
+		level, has_level := alert.Level()
+		crosses := has_level && alert.IsObserved() && config.Crosses(level)
+		matches := false
+		for _, pattern := range config.alert_patterns {
+			if pattern.MatchString(alert.Message) {
+				matches = true
+			}
+		}
+		if !crosses && !matches {
+			continue
+		}
+		scale := level.Scale
+		if !has_level {
+			scale = alert.Scale()
+		}
+		if scale == "" {
+			log.Printf("Skipping SWPC alert on no known scale: %v", alert.Headline())
+			continue
+		}
+		observation := Observation{Scale: scale, Time: alert.Issued, Note: alert.Issued.Format(noteTimeLayout) + ": SWPC " + alert.Headline()}
+		if crosses {
+			observation.Level = level
+		}
+		observations = append(observations, observation)
+	}
+	return observations
+}
+
+// CollectObservations polls the SWPC products, oldest observation first. A
+// product that fails to load is skipped until the next cycle.
+func CollectObservations(config SpaceWeatherConfig) []Observation {
+	var observations []Observation
+	kp_readings, err := swpc.FetchKp()
+	if err == nil {
+		observations = append(observations, kpObservations(kp_readings, config)...)
+	}
+	xray_readings, err := swpc.FetchXrays()
+	if err == nil {
+		observations = append(observations, xrayObservations(xray_readings, config)...)
+	}
+	alerts, err := swpc.FetchAlerts()
+	if err == nil {
+		observations = append(observations, alertObservations(alerts, config)...)
+	}
+	sort.SliceStable(observations, func(i, j int) bool { return observations[i].Time.Before(observations[j].Time) })
+	return observations
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"git.nunosempere.com/NunoSempere/news/lib/pubdate"
+	"git.nunosempere.com/NunoSempere/news/lib/web"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// DateFromURL reads the date off an article url, for sites which put it there
+func (site Site) DateFromURL(link string) (time.Time, bool) {
+	if site.DatePattern == nil {
+		return time.Time{}, false
+	}
+	matches := site.DatePattern.FindStringSubmatch(link)
+	if len(matches) != 4 {
+		return time.Time{}, false
+	}
+	date, err := time.Parse("2006-01-02", matches[1]+"-"+matches[2]+"-"+matches[3])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// ListArticles returns the article urls linked from a site's list pages,
+// without repeats
+func ListArticles(site Site) ([]string, error) {
+	var articles []string
+	seen := map[string]bool{}
+	var errs []error
+	for _, list_url := range site.ListURLs {
+		base, err := url.Parse(list_url)
+		if err != nil {
+			return nil, err
+		}
+		content, err := web.Get(list_url)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		links, err := web.GetUrls(bytes.NewReader(content))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, link := range links {
+			resolved, err := base.Parse(strings.TrimSpace(link))
+			if err != nil {
+				continue
+			}
+			resolved.Fragment = ""
+			article_url := resolved.String()
+			if !site.ArticlePattern.MatchString(article_url) || seen[article_url] {
+				continue
+			}
+			seen[article_url] = true
+			articles = append(articles, article_url)
+		}
+	}
+	if len(articles) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	log.Printf("Number of articles on %v: #%d", site.Name, len(articles))
+	return articles, nil
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// ExtractArticle fetches an article and reads its title, body and date
+func ExtractArticle(site Site, link string) (StateMediaSource, error) {
+	content, err := web.Get(link)
+	if err != nil {
+		return StateMediaSource{}, err
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(content))
+	if err != nil {
+		return StateMediaSource{}, err
+	}
+
+	title := ""
+	if site.TitleSelector != "" {
+		title = collapseSpaces(doc.Find(site.TitleSelector).First().Text())
+	}
+	if title == "" {
+		title, _, _ = strings.Cut(strings.TrimSpace(doc.Find("title").First().Text()), "\n")
+	}
+	body := collapseSpaces(doc.Find(site.ContentSelector).First().Text())
+	if body == "" {
+		return StateMediaSource{}, errors.New("No article content found at " + link)
+	}
+
+	// Extract date from URL, or failing that from the page itself
+	date, has_date := site.DateFromURL(link)
+	date_method := "url"
+	if !has_date {
+		published, ok := pubdate.Extract(content, link)
+		if ok {
+			date, date_method = published.Date, published.Method
+		} else {
+			date, date_method = time.Now(), "fetch time"
+		}
+	}
+	return StateMediaSource{Site: site, Link: link, Content: body, Title: title, Date: date, DateMethod: date_method, Html: content}, nil
+}
+
+// normalizeTitle lets the same article on different mirrors, or under
+// different urls, be recognised by its title
+func normalizeTitle(title string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			return -1
+		}
+		return r
+	}, title)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"git.nunosempere.com/NunoSempere/news/lib/filters"
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+	"log"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/snapshots"
+)
+
+func IsWithinTwoDays(articleDate time.Time) bool {
+	oneWeekAgo := time.Now().AddDate(0, 0, -2)
+	return articleDate.After(oneWeekAgo)
+}
+
+func TranslateArticle(article StateMediaSource, openai_token string) (StateMediaSourceTranslated, error) {
+	translated_title, err := llm.TranslateString(article.Title, openai_token)
+	if err != nil {
+		return StateMediaSourceTranslated{}, err
+	}
+	translated_content, err := llm.TranslateString(article.Content, openai_token)
+	if err != nil {
+		return StateMediaSourceTranslated{}, err
+	}
+	return StateMediaSourceTranslated{
+		Link:            article.Link,
+		OriginalTitle:   article.Title,
+		OriginalContent: article.Content,
+		EnglishTitle:    translated_title,
+		EnglishContent:  translated_content,
+	}, nil
+}
+
+// FilterAndExpandSource returns an error if a step failed in a way that's
+// worth retrying, e.g. an OpenAI error, and false, nil if the article was
+// filtered out
+func FilterAndExpandSource(article StateMediaSource, openai_key string, database_url string) (types.ExpandedSource, bool, error) {
+
+	is_dupe := filters.IsDupe(types.Source{Title: article.Title, Link: article.Link}, database_url)
+	if is_dupe {
+		return types.ExpandedSource{}, false, nil
+	}
+
+	translated, err := TranslateArticle(article, openai_key)
+	if err != nil {
+		log.Printf("%v", err)
+		return types.ExpandedSource{}, false, err
+	}
+	log.Printf("\nTranslated title: %s", translated.EnglishTitle)
+
+	expanded_source := types.ExpandedSource{
+		Title:      translated.EnglishTitle,
+		Link:       translated.Link,
+		Date:       article.Date.Format(time.RFC3339),
+		DateMethod: article.DateMethod,
+		Tags:       append([]string{"chinese-state-media"}, article.Site.Tags...),
+	}
+	if article.DateMethod != "url" && article.DateMethod != "fetch time" {
+		// we would have used the fetch time before reading a date off the page
+		expanded_source.OriginalDate = time.Now().Format(time.RFC3339)
+	}
+
+	// Keep the page we scored, so that it can be re-scored or shown later
+	snapshot_id, err := snapshots.Save(article.Link, article.Html, article.Content, database_url)
+	if err == nil {
+		expanded_source.SnapshotID = snapshot_id
+	}
+
+	summary, err := llm.Summarize(translated.EnglishContent + "\n\nWhen summarizing a Chinese article, give the gist in idiomatic English, rather than selecting the most important phrases in Chinese", openai_key)
+	if err != nil {
+		log.Printf("%v", err)
+		return expanded_source, false, err
+	}
+	expanded_source.Summary = summary
+	log.Printf("\nSummary: %s", expanded_source.Summary)
+
+	existential_importance_snippet := "# " + expanded_source.Title + "\n\n" + summary
+	existential_importance_box, err := llm.CheckExistentialImportanceChina(existential_importance_snippet, openai_key)
+	if err == nil && existential_importance_box == nil {
+		err = errors.New("No importance check result")
+	}
+	if err != nil {
+		log.Printf("%v", err)
+		return expanded_source, false, err
+	}
+	expanded_source.ImportanceBool = existential_importance_box.ExistentialImportanceBool
+	expanded_source.ImportanceReasoning = existential_importance_box.ExistentialImportanceReasoning
+	expanded_source.ImportancePrompt = "china"
+
+	log.Printf("Importance bool: %t", expanded_source.ImportanceBool)
+	log.Printf("Importance reasoning: %s", expanded_source.ImportanceReasoning)
+
+	return expanded_source, expanded_source.ImportanceBool, nil
+}
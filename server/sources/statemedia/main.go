@@ -0,0 +1,98 @@
+package main
+
+import (
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+
+	// Initialize logging
+	logFile, err := os.OpenFile("sources/statemedia/v2.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		log.Fatalf("error opening file: %v", err)
+	}
+	defer logFile.Close()
+	mw := io.MultiWriter(os.Stdout, logFile)
+	log.SetOutput(mw)
+
+	// Get keys
+	err = godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	openai_key := os.Getenv("OPENAI_KEY")
+	pg_database_url := os.Getenv("DATABASE_POOL_URL")
+
+	// The same article is carried by several outlets, and by the same
+	// outlet under several urls, so we remember titles across sites and
+	// batches. Titles are in Chinese, so the database can't tell us.
+	seen_titles := map[string]time.Time{}
+	for {
+		for _, site := range Sites {
+			article_urls, err := ListArticles(site)
+			if err != nil {
+				log.Printf("Error listing %v: %v", site.Name, err)
+				continue
+			}
+			for _, url := range article_urls {
+				log.Printf("Url: %s", url)
+
+				// filter here so as to not fetch full article if not necessary
+				date, has_date := site.DateFromURL(url)
+				if has_date && !IsWithinTwoDays(date) {
+					log.Printf("Article is stale")
+					continue
+				}
+
+				ms := 5000 + int64(2000*rand.Float32())
+				time.Sleep(time.Duration(ms) * time.Millisecond)
+				article, err := ExtractArticle(site, url)
+				if err != nil {
+					log.Print(err)
+					continue
+				}
+
+				// the url had no date, but the page might
+				if !has_date && article.DateMethod != "fetch time" && !IsWithinTwoDays(article.Date) {
+					log.Printf("Article is stale (%s date)", article.DateMethod)
+					continue
+				}
+
+				title_key := normalizeTitle(article.Title)
+				if _, seen := seen_titles[title_key]; seen {
+					log.Printf("Already seen on another url or site: %s", article.Title)
+					continue
+				}
+
+				log.Printf("Title [%v]: %s", site.Name, article.Title)
+
+				// A title is only marked as seen once it's been dealt with,
+				// so that if translating or saving fails, another url or
+				// the next batch tries again
+				expanded_source, passes_filters, err := FilterAndExpandSource(article, openai_key, pg_database_url)
+				if err == nil && passes_filters {
+					log.Println(expanded_source.Summary)
+					err = SaveSource(expanded_source)
+				}
+				if err == nil {
+					seen_titles[title_key] = time.Now()
+				}
+			}
+		}
+
+		// Articles older than two days are dropped anyway
+		for title, seen_at := range seen_titles {
+			if time.Since(seen_at) > 72*time.Hour {
+				delete(seen_titles, title)
+			}
+		}
+		log.Printf("Finished batch. Continuing in 12 hours")
+		time.Sleep(12 * time.Hour)
+	}
+}
@@ -10,30 +10,43 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
-func SaveSource(source types.ExpandedSource) {
+func SaveSource(source types.ExpandedSource) error {
 	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_POOL_URL"))
 	if err != nil {
 		log.Printf("Unable to connect to database: %v\n", err)
-		return
+		return err
 	}
 	defer conn.Close(context.Background())
 
 	date, err := time.Parse(time.RFC3339, source.Date)
 	if err != nil {
 		log.Printf("Error parsing date %v in saveSource: %v\n", source.Date, err)
-		return
+		return err
 	}
 
+	var original_date *time.Time
+	if source.OriginalDate != "" {
+		parsed, err := time.Parse(time.RFC3339, source.OriginalDate)
+		if err == nil {
+			original_date = &parsed
+		}
+	}
+
+	var snapshot_id *int64
+	if source.SnapshotID != 0 {
+		snapshot_id = &source.SnapshotID
+	}
 	_, err = conn.Exec(context.Background(), `
-        INSERT INTO sources (title, link, date, summary, importance_bool, importance_reasoning)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        INSERT INTO sources (title, link, date, summary, importance_bool, importance_reasoning, importance_prompt, original_date, date_method, snapshot_id, tags)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
         ON CONFLICT (link) DO NOTHING
-    `, source.Title, source.Link, date, source.Summary, source.ImportanceBool, source.ImportanceReasoning)
+    `, source.Title, source.Link, date, source.Summary, source.ImportanceBool, source.ImportanceReasoning, source.ImportancePrompt, original_date, source.DateMethod, snapshot_id, source.Tags)
 
 	if err != nil {
 		log.Printf("Error saving source to database: %v\n", err)
-		return
+		return err
 	}
 
 	log.Printf("Saved source: %v", source.Title)
+	return nil
 }
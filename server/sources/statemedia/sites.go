@@ -0,0 +1,82 @@
+package main
+
+import "regexp"
+
+/*
+Chinese official outlets. Each one supplies where to find its latest
+articles and how to read them; fetching, translation, deduplication across
+mirrors and the China importance check are shared.
+
+	ListURLs         front or section pages linking to the latest articles
+	ArticlePattern   links on those pages which are articles
+	ContentSelector  CSS selector for the article body
+	TitleSelector    CSS selector for the headline; the <title> otherwise
+	DatePattern      captures year, month and day in an article url, if it
+	                 has them; the date is read off the page otherwise
+*/
+
+type Site struct {
+	Name            string
+	ListURLs        []string
+	ArticlePattern  *regexp.Regexp
+	ContentSelector string
+	TitleSelector   string
+	DatePattern     *regexp.Regexp
+	Tags            []string
+}
+
+var Sites = []Site{
+	{
+		// Guangming Daily's military channel
+		Name:            "gmw-mil",
+		ListURLs:        []string{"https://mil.gmw.cn/"},
+		ArticlePattern:  regexp.MustCompile(`^https?://mil\.gmw\.cn/\d{4}-\d{2}/\d{2}/content_\d+\.htm`),
+		ContentSelector: "div.u-mainText, div.h-contentMain",
+		DatePattern:     regexp.MustCompile(`/(\d{4})-(\d{2})/(\d{2})/`), // /2025-02/10/content_37841910.htm
+		Tags:            []string{"gmw"},
+	},
+	{
+		// PLA Daily and China Military Online share 81.cn
+		Name:            "pla-daily",
+		ListURLs:        []string{"http://www.81.cn/", "http://www.81.cn/yw_208727/index.html"},
+		ArticlePattern:  regexp.MustCompile(`^https?://www\.81\.cn/[a-z_0-9]+/\d+\.html`),
+		ContentSelector: "#article-content, .article-content",
+		TitleSelector:   "h1",
+		Tags:            []string{"pla-daily"},
+	},
+	{
+		Name:            "china-military-online",
+		ListURLs:        []string{"http://www.chinamil.com.cn/"},
+		ArticlePattern:  regexp.MustCompile(`^https?://www\.chinamil\.com\.cn/[a-z_0-9/]+/\d+\.html`),
+		ContentSelector: "#article-content, .article-content",
+		TitleSelector:   "h1",
+		Tags:            []string{"china-military-online"},
+	},
+	{
+		// Global Times' Chinese edition
+		Name:            "huanqiu-mil",
+		ListURLs:        []string{"https://mil.huanqiu.com/"},
+		ArticlePattern:  regexp.MustCompile(`^https?://mil\.huanqiu\.com/article/[0-9A-Za-z]+`),
+		ContentSelector: "article, .article-content",
+		TitleSelector:   "h1",
+		Tags:            []string{"global-times"},
+	},
+	{
+		Name:            "xinhua-mil",
+		ListURLs:        []string{"http://www.news.cn/mil/"},
+		ArticlePattern:  regexp.MustCompile(`^https?://www\.news\.cn/mil/\d{8}/[0-9a-f]+/c\.html`),
+		ContentSelector: "#detailContent, #detail",
+		TitleSelector:   "h1",
+		DatePattern:     regexp.MustCompile(`/(\d{4})(\d{2})(\d{2})/`), // /mil/20250210/9b5c.../c.html
+		Tags:            []string{"xinhua"},
+	},
+	{
+		Name:            "taiwan-affairs-office",
+		ListURLs:        []string{"http://www.gwytb.gov.cn/xwdt/xwfb/wyly/", "http://www.gwytb.gov.cn/xwdt/zwyw/"},
+		ArticlePattern:  regexp.MustCompile(`^https?://www\.gwytb\.gov\.cn/.+/t\d{8}_\d+\.htm`),
+		ContentSelector: ".TRS_Editor, .zwnr",
+		TitleSelector:   "h1",
+		DatePattern:     regexp.MustCompile(`/t(\d{4})(\d{2})(\d{2})_`), // /202502/t20250210_12680000.htm
+		Tags:            []string{"taiwan-affairs-office"},
+	},
+}
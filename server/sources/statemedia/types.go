@@ -0,0 +1,21 @@
+package main
+
+import "time"
+
+type StateMediaSource struct {
+	Site       Site
+	Link       string
+	Title      string
+	Content    string
+	Date       time.Time
+	DateMethod string // "url", a lib/pubdate method, or "fetch time"
+	Html       []byte
+}
+
+type StateMediaSourceTranslated struct {
+	Link            string
+	OriginalTitle   string
+	OriginalContent string
+	EnglishTitle    string
+	EnglishContent  string
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"gopkg.in/yaml.v3"
+)
+
+// See config/telegram.yaml
+type ListConfig struct {
+	Name     string   `yaml:"name"`
+	Channels []string `yaml:"channels"`
+	Tags     []string `yaml:"tags"`
+	Prompt   string   `yaml:"prompt"`
+}
+
+type TelegramConfig struct {
+	Cadence   string       `yaml:"cadence"`
+	FreshDays int          `yaml:"fresh_days"`
+	MaxPages  int          `yaml:"max_pages"`
+	Lists     []ListConfig `yaml:"lists"`
+
+	cadence time.Duration
+}
+
+// Public channel usernames, as in t.me/<channel>
+var channelPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{3,31}$`)
+
+// LoadConfig reads config/telegram.yaml, fills in defaults, and refuses to
+// start on a config we would only notice was broken hours later
+func LoadConfig(path string) (TelegramConfig, error) {
+	config := TelegramConfig{Cadence: "15m", FreshDays: 2, MaxPages: 5}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return config, fmt.Errorf("Error parsing %v: %v", path, err)
+	}
+	config.cadence, err = time.ParseDuration(config.Cadence)
+	if err != nil || config.cadence < 5*time.Minute {
+		return config, fmt.Errorf("Invalid cadence: %q", config.Cadence)
+	}
+	if config.MaxPages < 1 {
+		return config, fmt.Errorf("max_pages should be at least 1, got: %d", config.MaxPages)
+	}
+	if len(config.Lists) == 0 {
+		return config, errors.New("No lists in " + path)
+	}
+	seen := map[string]bool{}
+	for i := range config.Lists {
+		list := &config.Lists[i]
+		if list.Name == "" || len(list.Channels) == 0 {
+			return config, fmt.Errorf("List #%d needs a name and channels", i+1)
+		}
+		for _, channel := range list.Channels {
+			if !channelPattern.MatchString(channel) {
+				return config, fmt.Errorf("List %q: %q isn't a channel username", list.Name, channel)
+			}
+			if seen[channel] {
+				return config, fmt.Errorf("Channel %q is listed twice", channel)
+			}
+			seen[channel] = true
+		}
+		if list.Prompt == "" {
+			list.Prompt = "default"
+		}
+		if _, exists := llm.ImportanceChecks[list.Prompt]; !exists {
+			return config, fmt.Errorf("List %q has an unknown prompt: %q", list.Name, list.Prompt)
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/feedstate"
+	"git.nunosempere.com/NunoSempere/news/lib/telegram"
+)
+
+// A message, with the settings of the list its channel is in
+type MessageItem struct {
+	telegram.Message
+	List ListConfig
+}
+
+// FetchChannel returns the messages we haven't processed, from those
+// posted after the state's LastEntryID, which is kept in feed_state under
+// the channel's preview url. The caller saves the state once it has
+// processed them, see feedstate.Advance and advanceCursor.
+func FetchChannel(channel string, list ListConfig, max_pages int, database_url string) ([]MessageItem, feedstate.FeedState, error) {
+	state, err := feedstate.Load(telegram.PreviewURL(channel, 0), database_url)
+	if err != nil {
+		state.Url = telegram.PreviewURL(channel, 0)
+	}
+	last_id, _ := strconv.Atoi(state.LastEntryID)
+
+	messages, err := telegram.MessagesAfter(channel, last_id, max_pages)
+	if err != nil {
+		return nil, state, err
+	}
+	state.LastSuccessAt = time.Now()
+	state.NumItems = len(messages)
+
+	var message_ids []string
+	for _, message := range messages {
+		message_ids = append(message_ids, strconv.Itoa(message.ID))
+	}
+	state, new_messages := feedstate.NewEntries(state, message_ids)
+
+	var items []MessageItem
+	for _, message := range messages {
+		if !new_messages[strconv.Itoa(message.ID)] {
+			continue
+		}
+		items = append(items, MessageItem{Message: message, List: list})
+	}
+	return items, state, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"git.nunosempere.com/NunoSempere/news/lib/filters"
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"git.nunosempere.com/NunoSempere/news/lib/pipeline"
+	"git.nunosempere.com/NunoSempere/news/lib/types"
+)
+
+// needsTranslation is true for mostly Cyrillic or Arabic script text, i.e.
+// Russian, Ukrainian, Arabic, Persian, which is most of what these
+// channels post in
+func needsTranslation(text string) bool {
+	num_letters, num_foreign := 0, 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		num_letters++
+		if unicode.In(r, unicode.Cyrillic, unicode.Arabic) {
+			num_foreign++
+		}
+	}
+	return num_letters > 0 && float64(num_foreign)/float64(num_letters) > 0.3
+}
+
+func titleOf(channel string, text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) > 120 {
+		text = string([]rune(text)[:120]) + "…"
+	}
+	return "[" + channel + "] " + text
+}
+
+// FilterAndExpandMessage scores the message itself, translated to English
+// if need be. Telegram posts are often the first report of a strike or an
+// attack, and the articles come hours later.
+func FilterAndExpandMessage(item MessageItem, fresh_days int, openai_key string, database_url string) (types.ExpandedSource, bool, error) {
+	expanded_source := types.ExpandedSource{
+		Title:      titleOf(item.Channel, item.Text),
+		Link:       item.URL(),
+		Date:       item.Date.Format(time.RFC3339),
+		DateMethod: "telegram",
+		Summary:    item.Text,
+		Tags:       append([]string{"telegram", "channel:" + item.Channel, "list:" + item.List.Name}, item.List.Tags...),
+	}
+
+	is_dupe := filters.IsDupe(types.Source{Title: expanded_source.Title, Link: expanded_source.Link}, database_url)
+	if is_dupe {
+		return expanded_source, false, nil
+	}
+	if !pipeline.IsFresh(item.Date, fresh_days) {
+		log.Printf("Message is not fresh")
+		return expanded_source, false, nil
+	}
+
+	if needsTranslation(item.Text) {
+		translation, err := llm.TranslateString(item.Text, openai_key)
+		if err != nil {
+			log.Printf("Translation failed for %s: %v", expanded_source.Link, err)
+			return expanded_source, false, err
+		}
+		expanded_source.Title = titleOf(item.Channel, translation)
+		expanded_source.Summary = translation + "\n\n[Translated. Original:] " + item.Text
+		expanded_source.Tags = append(expanded_source.Tags, "translated")
+		log.Printf("Translation: %s", translation)
+	}
+
+	err := pipeline.CheckImportance(&expanded_source, item.List.Prompt, openai_key)
+	if err != nil {
+		return expanded_source, false, err
+	}
+	return expanded_source, expanded_source.ImportanceBool, nil
+}
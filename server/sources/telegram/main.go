@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/feedstate"
+	"git.nunosempere.com/NunoSempere/news/lib/pipeline"
+	"github.com/joho/godotenv"
+)
+
+func processChannel(channel string, list ListConfig, config TelegramConfig, openai_key string, pg_database_url string) {
+	items, state, err := FetchChannel(channel, list, config.MaxPages, pg_database_url)
+	if err != nil {
+		log.Printf("Error fetching channel %v: %v", channel, err)
+		return
+	}
+	if len(items) > 0 {
+		log.Printf("Channel %v has %d new messages", channel, len(items))
+	}
+
+	// Messages whose translation, importance check or save failed are
+	// retried next cycle, see feedstate.Advance
+	var message_ids []string
+	failed := map[string]bool{}
+	for i, item := range items {
+		log.Printf("\n\nMessage #%v/%v [%v]: %v\n", i+1, len(items), channel, item.URL())
+		expanded_source, passes_filters, err := FilterAndExpandMessage(item, config.FreshDays, openai_key, pg_database_url)
+		if err == nil && passes_filters {
+			err = pipeline.Save(expanded_source, pg_database_url)
+			if err == pipeline.ErrAlreadySaved {
+				err = nil
+			}
+		}
+		message_ids = append(message_ids, strconv.Itoa(item.ID))
+		if err != nil {
+			failed[strconv.Itoa(item.ID)] = true
+		}
+	}
+	state = feedstate.Advance(state, message_ids, failed)
+	feedstate.Save(advanceCursor(state), pg_database_url)
+}
+
+// advanceCursor moves LastEntryID, which the next fetch starts after, up
+// to the newest message which it and all messages before it are done
+func advanceCursor(state feedstate.FeedState) feedstate.FeedState {
+	last_id, _ := strconv.Atoi(state.LastEntryID)
+	done := map[int]bool{}
+	for _, id := range state.DoneEntryIDs {
+		message_id, err := strconv.Atoi(id)
+		if err == nil {
+			done[message_id] = true
+		}
+	}
+	pending := 0
+	for id := range state.Attempts {
+		message_id, err := strconv.Atoi(id)
+		if err == nil && (pending == 0 || message_id < pending) {
+			pending = message_id
+		}
+	}
+	for id := range done {
+		if id > last_id && (pending == 0 || id < pending) {
+			last_id = id
+		}
+	}
+	state.LastEntryID = strconv.Itoa(last_id)
+	return state
+}
+
+func main() {
+
+	logFile, err := os.OpenFile("sources/telegram/v2.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		log.Fatalf("error opening file: %v", err)
+	}
+	defer logFile.Close()
+	mw := io.MultiWriter(os.Stdout, logFile)
+	log.SetOutput(mw)
+
+	// Get keys
+	err = godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	openai_key := os.Getenv("OPENAI_KEY")
+	pg_database_url := os.Getenv("DATABASE_POOL_URL")
+
+	config, err := LoadConfig("config/telegram.yaml")
+	if err != nil {
+		log.Fatalf("Error loading telegram config: %v", err)
+	}
+
+	ticker := time.NewTicker(config.cadence)
+	defer ticker.Stop()
+	for ; true; <-ticker.C {
+		for _, list := range config.Lists {
+			for _, channel := range list.Channels {
+				processChannel(channel, list, config, openai_key, pg_database_url)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"git.nunosempere.com/NunoSempere/news/lib/twitter"
+	"gopkg.in/yaml.v3"
+)
+
+// See config/tweets.yaml
+type ListConfig struct {
+	Name     string   `yaml:"name"`
+	Accounts []string `yaml:"accounts"`
+	Tags     []string `yaml:"tags"`
+}
+
+type QueryConfig struct {
+	Query string   `yaml:"query"`
+	Tags  []string `yaml:"tags"`
+}
+
+type TweetsConfig struct {
+	API        string `yaml:"api"`
+	Cadence    string `yaml:"cadence"`
+	Retweets   bool   `yaml:"retweets"`
+	Importance struct {
+		Enabled bool   `yaml:"enabled"`
+		Prompt  string `yaml:"prompt"`
+	} `yaml:"importance"`
+	Lists   []ListConfig  `yaml:"lists"`
+	Queries []QueryConfig `yaml:"queries"`
+
+	cadence time.Duration
+}
+
+// LoadConfig reads config/tweets.yaml, fills in defaults, and refuses to
+// start on a config we would only notice was broken hours later
+func LoadConfig(path string) (TweetsConfig, error) {
+	config := TweetsConfig{API: "twitter-api45", Cadence: "1h"}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return config, fmt.Errorf("Error parsing %v: %v", path, err)
+	}
+	if _, exists := twitter.Clients[config.API]; !exists {
+		return config, fmt.Errorf("Unknown api: %q", config.API)
+	}
+	config.cadence, err = time.ParseDuration(config.Cadence)
+	if err != nil || config.cadence < 5*time.Minute {
+		return config, fmt.Errorf("Invalid cadence: %q", config.Cadence)
+	}
+	if config.Importance.Prompt == "" {
+		config.Importance.Prompt = "default"
+	}
+	if _, exists := llm.ImportanceChecks[config.Importance.Prompt]; !exists {
+		return config, fmt.Errorf("Unknown importance prompt: %q", config.Importance.Prompt)
+	}
+	if len(config.Lists) == 0 && len(config.Queries) == 0 {
+		return config, errors.New("No lists or queries in " + path)
+	}
+	for i := range config.Lists {
+		list := &config.Lists[i]
+		if list.Name == "" || len(list.Accounts) == 0 {
+			return config, fmt.Errorf("List #%d needs a name and accounts", i+1)
+		}
+		for j, account := range list.Accounts {
+			list.Accounts[j] = strings.TrimPrefix(strings.TrimSpace(account), "@")
+		}
+	}
+	for i := range config.Queries {
+		query := &config.Queries[i]
+		query.Query = strings.TrimSpace(query.Query)
+		if query.Query == "" {
+			return config, fmt.Errorf("Query #%d is empty", i+1)
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	"git.nunosempere.com/NunoSempere/news/lib/twitter"
+)
+
+// A tweet, with the tags of the list or query it came from
+type TweetItem struct {
+	twitter.Tweet
+	Tags []string
+}
+
+func isRetweet(tweet twitter.Tweet) bool {
+	return strings.HasPrefix(tweet.Text, "RT @")
+}
+
+// FetchTweets polls every account and query once. A failing account or
+// query is logged and skipped, so that one suspended account doesn't stop
+// the rest.
+func FetchTweets(client twitter.Client, config TweetsConfig) []TweetItem {
+	var items []TweetItem
+	seen := map[string]bool{}
+	add := func(tweets []twitter.Tweet, tags []string) {
+		for _, tweet := range tweets {
+			if seen[tweet.ID] || (isRetweet(tweet) && !config.Retweets) {
+				continue
+			}
+			seen[tweet.ID] = true
+			items = append(items, TweetItem{Tweet: tweet, Tags: tags})
+		}
+	}
+
+	for _, list := range config.Lists {
+		tags := append([]string{"list:" + list.Name}, list.Tags...)
+		for _, account := range list.Accounts {
+			tweets, err := client.UserTweets(account)
+			if err != nil {
+				log.Printf("Error fetching tweets from @%v: %v", account, err)
+				continue
+			}
+			add(tweets, tags)
+		}
+	}
+	for _, query := range config.Queries {
+		tweets, err := client.Search(query.Query)
+		if err != nil {
+			log.Printf("Error searching for %q: %v", query.Query, err)
+			continue
+		}
+		add(tweets, append([]string{"query:" + query.Query}, query.Tags...))
+	}
+	return items
+}
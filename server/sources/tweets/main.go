@@ -0,0 +1,70 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"git.nunosempere.com/NunoSempere/news/lib/twitter"
+	"github.com/joho/godotenv"
+)
+
+func processTweets(client twitter.Client, config TweetsConfig, openai_key string) {
+	items := FilterNewTweets(FetchTweets(client, config))
+	log.Printf("Found %d new tweets", len(items))
+
+	for i, item := range items {
+		log.Printf("\n\nTweet #%v/%v: @%v: %v (%v)\n", i+1, len(items), item.Author, item.Text, item.URL())
+		if !config.Importance.Enabled {
+			SaveTweet(item, nil)
+			continue
+		}
+		check_importance := llm.ImportanceChecks[config.Importance.Prompt]
+		importance, err := check_importance("@"+item.Author+": "+item.Text, openai_key)
+		if err != nil || importance == nil {
+			// Not saved, so that it's checked again next cycle
+			log.Printf("Importance check failed for %s: %v", item.URL(), err)
+			continue
+		}
+		log.Printf("Importance bool: %t", importance.ExistentialImportanceBool)
+		log.Printf("Reasoning: %s", importance.ExistentialImportanceReasoning)
+		// Saved either way, so that unimportant tweets aren't checked again
+		SaveTweet(item, importance)
+	}
+}
+
+func main() {
+
+	logFile, err := os.OpenFile("sources/tweets/v2.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		log.Fatalf("error opening file: %v", err)
+	}
+	defer logFile.Close()
+	mw := io.MultiWriter(os.Stdout, logFile)
+	log.SetOutput(mw)
+
+	// Get keys
+	err = godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	openai_key := os.Getenv("OPENAI_KEY")
+	rapidapi_key := os.Getenv("RAPIDAPI_KEY")
+	if rapidapi_key == "" {
+		log.Fatal("RAPIDAPI_KEY is not set")
+	}
+
+	config, err := LoadConfig("config/tweets.yaml")
+	if err != nil {
+		log.Fatalf("Error loading tweets config: %v", err)
+	}
+	client := twitter.Clients[config.API](rapidapi_key)
+
+	ticker := time.NewTicker(config.cadence)
+	defer ticker.Stop()
+	for ; true; <-ticker.C {
+		processTweets(client, config, openai_key)
+	}
+}
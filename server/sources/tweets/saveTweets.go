@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"github.com/jackc/pgx/v5"
+)
+
+// FilterNewTweets drops the tweets already in the tweets table
+func FilterNewTweets(items []TweetItem) []TweetItem {
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_POOL_URL"))
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return nil
+	}
+	defer conn.Close(context.Background())
+
+	var ids []string
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	rows, err := conn.Query(context.Background(), `SELECT tweetid FROM tweets WHERE tweetid = ANY($1)`, ids)
+	if err != nil {
+		log.Printf("Error querying tweets: %v\n", err)
+		return nil
+	}
+	existing, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		log.Printf("Error reading tweets: %v\n", err)
+		return nil
+	}
+	is_existing := map[string]bool{}
+	for _, id := range existing {
+		is_existing[id] = true
+	}
+
+	var new_items []TweetItem
+	for _, item := range items {
+		if !is_existing[item.ID] {
+			new_items = append(new_items, item)
+		}
+	}
+	return new_items
+}
+
+// SaveTweet saves a tweet, and the result of its importance check if it
+// had one
+func SaveTweet(item TweetItem, importance *llm.ExistentialImportanceBox) {
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_POOL_URL"))
+	if err != nil {
+		log.Printf("Unable to connect to database: %v\n", err)
+		return
+	}
+	defer conn.Close(context.Background())
+
+	var importance_bool *bool
+	var importance_reasoning *string
+	if importance != nil {
+		importance_bool = &importance.ExistentialImportanceBool
+		importance_reasoning = &importance.ExistentialImportanceReasoning
+	}
+
+	_, err = conn.Exec(context.Background(), `
+        INSERT INTO tweets (tweetid, text, author, url, created_at, importance_bool, importance_reasoning, tags)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        ON CONFLICT (tweetid) DO NOTHING
+    `, item.ID, item.Text, item.Author, item.URL(), item.CreatedAt, importance_bool, importance_reasoning, item.Tags)
+	if err != nil {
+		log.Printf("Error saving tweet to database: %v\n", err)
+		return
+	}
+	log.Printf("Saved tweet: %v\n", item.URL())
+}
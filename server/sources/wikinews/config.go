@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"git.nunosempere.com/NunoSempere/news/lib/llm"
+	"gopkg.in/yaml.v3"
+)
+
+// See config/wikinews.yaml
+type Config struct {
+	Prompts map[string]string `yaml:"prompts"`
+	Skip    []string          `yaml:"skip"`
+}
+
+func LoadConfig(path string) (Config, error) {
+	var config Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return config, fmt.Errorf("Error parsing %v: %v", path, err)
+	}
+	for category, prompt := range config.Prompts {
+		if _, exists := llm.ImportanceChecks[prompt]; !exists {
+			return config, fmt.Errorf("Category %q has an unknown prompt: %q", category, prompt)
+		}
+	}
+	return config, nil
+}
+
+func (c Config) PromptFor(category string) string {
+	if prompt, exists := c.Prompts[category]; exists {
+		return prompt
+	}
+	return "default"
+}
+
+func (c Config) Skips(category string) bool {
+	return slices.Contains(c.Skip, category)
+}
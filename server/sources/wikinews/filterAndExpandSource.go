@@ -1,79 +1,111 @@
 package main
 
 import (
-	"time"
+	"errors"
+	"fmt"
 	"log"
+	"time"
 
+	"git.nunosempere.com/NunoSempere/news/lib/currentevents"
 	"git.nunosempere.com/NunoSempere/news/lib/filters"
 	"git.nunosempere.com/NunoSempere/news/lib/llm"
 	"git.nunosempere.com/NunoSempere/news/lib/readability"
+	"git.nunosempere.com/NunoSempere/news/lib/snapshots"
 	"git.nunosempere.com/NunoSempere/news/lib/types"
 )
 
-// FilterAndExpandSource processes a wikinews source through various filters,
-// expands its content (via summarization and importance check),
-// and returns an ExpandedSource and a boolean indicating if it passes thresholds.
-func FilterAndExpandSource(source types.Source, openai_key string, database_url string) (types.ExpandedSource, bool) {
-	// Try to get a better title from the source HTML
-	// Since wikinews external links don't provide a publication date,
-	// we use the current time.
-	expanded_source := types.ExpandedSource{
-		Title: source.Title,
-		Link:  source.Link,
-		Date:  time.Now().Format(time.RFC3339),
+// firstReadable returns the first citation on a good host whose article
+// we can extract
+func firstReadable(links []string) (string, readability.Article, bool) {
+	for _, link := range links {
+		if !filters.IsGoodHost(types.Source{Link: link}) {
+			continue
+		}
+		article, err := readability.GetArticle(link)
+		if err != nil {
+			log.Printf("Readability extraction failed for %s: %v", link, err)
+			continue
+		}
+		return link, article, true
 	}
+	return "", readability.Article{}, false
+}
 
-	// Check for duplicates.
-	is_dupe := filters.IsDupe(source, database_url)
-	if is_dupe {
-		return expanded_source, false
+// FilterAndExpandEvent takes a Current Events bullet, titles it with its
+// description, and scores it on its first readable citation, with the
+// importance prompt for its category. It returns an error if the summary
+// or importance check failed, so that the event is retried.
+func FilterAndExpandEvent(event currentevents.Event, prompt string, openai_key string, database_url string) (types.ExpandedSource, bool, error) {
+	tags := []string{"wikipedia-current-events"}
+	if event.Category != "" {
+		tags = append(tags, "category:"+event.Category)
 	}
-
-	// Assume the article is fresh since we have no publication timestamp.
-	// (Alternatively, one might try to extract a date from the article.)
-	
-	// Check if host is acceptable.
-	is_good_host := filters.IsGoodHost(source)
-	if !is_good_host {
-		return expanded_source, false
+	for _, topic := range event.Topics {
+		tags = append(tags, "topic:"+topic)
 	}
-
-	if title := readability.ExtractTitle(source.Link); title != "" {
-		expanded_source.Title = title
-		log.Printf("Found title from HTML: %s", title)
+	expanded_source := types.ExpandedSource{
+		Title:      event.Description,
+		Date:       event.Date.Format(time.RFC3339),
+		DateMethod: "current events page",
+		Tags:       tags,
 	}
+	if len(event.Links) == 0 {
+		log.Printf("Event has no citations")
+		return expanded_source, false, nil
+	}
+	expanded_source.Link = event.Links[0]
 
-	// Clean up the title.
-	// log.Printf("Previous title: %v", expanded_source.Title)
-	expanded_source.Title = filters.CleanTitle(expanded_source.Title)
+	// Any of its citations having been saved means we've seen the event
+	for _, link := range event.Links {
+		if filters.IsDupe(types.Source{Title: event.Description, Link: link}, database_url) {
+			return expanded_source, false, nil
+		}
+	}
 
-	// Get article content using a readability extractor.
-	content, err := readability.GetArticleContent(source.Link)
-	if err != nil {
-		log.Printf("Readability extraction failed for %s: %v", source.Link, err)
-		return expanded_source, false
+	link, article, ok := firstReadable(event.Links)
+	var summary string
+	switch {
+	case !ok:
+		// Wikipedia's own line is still worth scoring
+		summary = "[Citations unreadable, Wikipedia's description only] " + event.Description
+	case article.TitleOnly:
+		// Paywalled: score on the title and lede rather than dropping it
+		expanded_source.Link = link
+		summary = "[Paywalled, lede only] " + article.Content
+	default:
+		expanded_source.Link = link
+		var err error
+		summary, err = llm.Summarize(article.Content, openai_key)
+		if err != nil {
+			log.Printf("Summarization failed for %s: %v", link, err)
+			return expanded_source, false, err
+		}
 	}
-	
-	// Summarize the article using an LLM.
-	summary, err := llm.Summarize(content, openai_key)
-	if err != nil {
-		log.Printf("Summarization failed for %s: %v", source.Link, err)
-		return expanded_source, false
+	if ok {
+		// Keep the page we scored, so that it can be re-scored or shown later
+		snapshot_id, err := snapshots.Save(link, article.Html, article.Content, database_url)
+		if err == nil {
+			expanded_source.SnapshotID = snapshot_id
+		}
 	}
 	expanded_source.Summary = summary
 	log.Printf("Summary: %s", expanded_source.Summary)
 
-	// Check existential or importance threshold.
-	existential_importance_snippet := "# " + expanded_source.Title + "\n\n" + summary
-	existential_importance_box, err := llm.CheckExistentialImportance(existential_importance_snippet, openai_key)
-	if err != nil || existential_importance_box == nil {
-		log.Printf("Importance check failed for %s: %v", source.Link, err)
-		return expanded_source, false
+	existential_importance_snippet := "# " + expanded_source.Title + "\n\n" + summary +
+		fmt.Sprintf("\n\nWikipedia Current Events: %s, cited by %d sources", event.Path(), len(event.Links))
+	existential_importance_box, err := llm.ImportanceChecks[prompt](existential_importance_snippet, openai_key)
+	if err == nil && existential_importance_box == nil {
+		err = errors.New("No importance check result")
+	}
+	if err != nil {
+		log.Printf("Importance check failed for %s: %v", expanded_source.Link, err)
+		return expanded_source, false, err
 	}
 	expanded_source.ImportanceBool = existential_importance_box.ExistentialImportanceBool
 	expanded_source.ImportanceReasoning = existential_importance_box.ExistentialImportanceReasoning
+	expanded_source.ImportancePrompt = prompt
 	log.Printf("Importance bool: %t", expanded_source.ImportanceBool)
 	log.Printf("Reasoning: %s", expanded_source.ImportanceReasoning)
 
-	return expanded_source, expanded_source.ImportanceBool
+	return expanded_source, expanded_source.ImportanceBool, nil
 }
@@ -1,13 +1,14 @@
 package main
 
 import (
+	"io"
 	"log"
 	"os"
-	"io"
 	"time"
 
+	"git.nunosempere.com/NunoSempere/news/lib/feedstate"
+	"git.nunosempere.com/NunoSempere/news/lib/pipeline"
 	"github.com/joho/godotenv"
-	"git.nunosempere.com/NunoSempere/news/lib/types"
 )
 
 func main() {
@@ -28,47 +29,58 @@ func main() {
 	openai_key := os.Getenv("OPENAI_KEY")
 	pg_database_url := os.Getenv("DATABASE_POOL_URL")
 
+	config, err := LoadConfig("config/wikinews.yaml")
+	if err != nil {
+		log.Fatalf("Error loading wikinews config: %v", err)
+	}
+
+	// Events are added to a day's page through that day and the next, so
+	// we read both. Which events on each we've processed is kept in its
+	// feed state, by description.
 	for {
 		log.Println("Starting Wikipedia current events processing")
-		rssURL := "https://www.to-rss.xyz/wikipedia/current_events/"
-		
-		link, err := ExtractCurrentEventsLink(rssURL)
-		if err != nil {
-			log.Printf("Error extracting current events link: %v", err)
-			continue
-		}
-		if link == "" {
-			log.Printf("No current events link found")
-			continue
-		}
-		log.Printf("Current events link: %s", link)
-		
-		// Fetch the content
-		content, err := FetchCurrentEvents(link)
-		if err != nil {
-			log.Printf("Error fetching current events: %v", err)
-			continue
-		}
-		
-		// Extract and process external links
-		externalLinks := ExtractExternalLinks(content)
-		log.Printf("Found %d external news source links", len(externalLinks))
-		
-		// Process each external link
-		for i, extLink := range externalLinks {
-			log.Printf("\nProcessing link %d/%d: %s", i+1, len(externalLinks), extLink)
-			source := types.Source{
-				Title: extLink,
-				Link:  extLink,
-				Date:  "", // FilterAndExpandSource will set date
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+			events, state, unchanged, err := FetchDay(day, pg_database_url)
+			if err != nil {
+				log.Printf("Error fetching current events for %v: %v", day.Format("2006-01-02"), err)
+				continue
 			}
-			expanded_source, passes_filters := FilterAndExpandSource(source, openai_key, pg_database_url)
-			if passes_filters {
-				SaveSource(expanded_source)
+			if unchanged {
+				log.Printf("Current events for %v unchanged", day.Format("2006-01-02"))
+				continue
 			}
-		}
 
-		log.Printf("Finished processing current events, sleeping for 24 hours")
-		time.Sleep(12 * time.Hour)
+			var descriptions []string
+			for _, event := range events {
+				descriptions = append(descriptions, event.Description)
+			}
+			state, new_events := feedstate.NewEntries(state, descriptions)
+
+			// Events which errored aren't saved, and are retried next time,
+			// see feedstate.Advance
+			var processed []string
+			failed := map[string]bool{}
+			for i, event := range events {
+				if !new_events[event.Description] {
+					continue
+				}
+				processed = append(processed, event.Description)
+				if config.Skips(event.Category) {
+					continue
+				}
+				log.Printf("\nProcessing event %d/%d [%v]: %s", i+1, len(events), event.Path(), event.Description)
+				expanded_source, passes_filters, err := FilterAndExpandEvent(event, config.PromptFor(event.Category), openai_key, pg_database_url)
+				if err == nil && passes_filters {
+					err = pipeline.Save(expanded_source, pg_database_url)
+				}
+				if err != nil && err != pipeline.ErrAlreadySaved {
+					failed[event.Description] = true
+				}
+			}
+			feedstate.Save(feedstate.Advance(state, processed, failed), pg_database_url)
+		}
+		log.Printf("Finished processing current events, sleeping for 3 hours")
+		time.Sleep(3 * time.Hour)
 	}
 }